@@ -0,0 +1,103 @@
+package dew
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// AuditSink receives audit log entries.
+type AuditSink interface {
+	// Record persists the audit entry.
+	Record(ctx context.Context, entry AuditEntry)
+}
+
+// AuditEntry describes the execution of an action.
+type AuditEntry struct {
+	// Command is the type name of the action.
+	Command string
+	// Fields holds the exported fields of the action.
+	// Fields tagged with `audit:"redact"` are replaced with Redacted.
+	Fields map[string]any
+	// Time is when the action started.
+	Time time.Time
+	// Actor is the actor set with WithActor, or the user of the Identity if none is set.
+	Actor string
+	// Identity is the identity set with WithIdentity, if any.
+	Identity Identity
+	// Err is the error returned by the action, nil on success.
+	Err error
+}
+
+// Redacted is the value recorded for fields tagged with `audit:"redact"`.
+const Redacted = "[REDACTED]"
+
+type actorKey struct{}
+
+// WithActor returns a new context with the given actor for audit logs.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+// Audit returns a middleware that records an audit entry to the sink for every action.
+// Queries are not audited, even if the middleware is registered for them:
+//
+//	bus.Use(dew.ACTION, dew.Audit(sink))
+func Audit(sink AuditSink) func(next Middleware) Middleware {
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			if ctx.Op() != ACTION {
+				return next.Handle(ctx)
+			}
+			entry := AuditEntry{Time: clockFrom(ctx.Context()).Now()}
+			if cmd := ctx.Command(); cmd != nil {
+				entry.Command, entry.Fields = auditFields(cmd)
+			}
+			entry.Identity, _ = IdentityFromContext(ctx.Context())
+			var ok bool
+			if entry.Actor, ok = ActorFromContext(ctx.Context()); !ok {
+				entry.Actor = entry.Identity.UserID
+			}
+
+			err := next.Handle(ctx)
+
+			entry.Err = err
+			sink.Record(ctx.Context(), entry)
+			return err
+		})
+	}
+}
+
+// auditFields returns the type name and the redacted exported fields of the command.
+func auditFields(cmd Command) (string, map[string]any) {
+	v := reflect.ValueOf(cmd)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v.Type().Elem().Name(), nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return v.Type().Name(), nil
+	}
+	t := v.Type()
+	fields := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Tag.Get("audit") == "redact" {
+			fields[f.Name] = Redacted
+			continue
+		}
+		fields[f.Name] = v.Field(i).Interface()
+	}
+	return t.Name(), fields
+}
@@ -0,0 +1,99 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type fakeAuditSink struct {
+	mu      sync.Mutex
+	entries []dew.AuditEntry
+}
+
+func (s *fakeAuditSink) Record(_ context.Context, entry dew.AuditEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+}
+
+type changePassword struct {
+	UserID   int
+	Password string `audit:"redact"`
+}
+
+func (changePassword) Validate(context.Context) error { return nil }
+
+func TestAudit(t *testing.T) {
+	errWeak := errors.New("weak password")
+	sink := &fakeAuditSink{}
+
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.Audit(sink))
+	mux.Register(dew.HandlerFunc[changePassword](
+		func(ctx context.Context, command *changePassword) error {
+			if len(command.Password) < 4 {
+				return errWeak
+			}
+			return nil
+		},
+	))
+	mux.Register(new(userHandler))
+	ctx := dew.WithActor(dew.NewContext(context.Background(), mux), "admin")
+
+	if _, err := dew.Dispatch(ctx, &changePassword{UserID: 1, Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dew.Dispatch(ctx, &changePassword{UserID: 2, Password: "x"}); !errors.Is(err, errWeak) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// queries are not audited
+	testRunQuery(t, ctx, &findUser{ID: 1})
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("unexpected entries: %v", sink.entries)
+	}
+	for i, entry := range sink.entries {
+		if entry.Command != "changePassword" {
+			t.Errorf("unexpected command: %s", entry.Command)
+		}
+		if entry.Actor != "admin" {
+			t.Errorf("unexpected actor: %s", entry.Actor)
+		}
+		if entry.Fields["UserID"] != i+1 {
+			t.Errorf("unexpected user id: %v", entry.Fields["UserID"])
+		}
+		if entry.Fields["Password"] != dew.Redacted {
+			t.Errorf("expected password to be redacted, got: %v", entry.Fields["Password"])
+		}
+		if entry.Time.IsZero() {
+			t.Error("expected timestamp")
+		}
+	}
+	if sink.entries[0].Err != nil {
+		t.Errorf("unexpected error: %v", sink.entries[0].Err)
+	}
+	if !errors.Is(sink.entries[1].Err, errWeak) {
+		t.Errorf("unexpected error: %v", sink.entries[1].Err)
+	}
+}
+
+func TestAudit_AllOps(t *testing.T) {
+	sink := &fakeAuditSink{}
+
+	mux := dew.New()
+	mux.Use(dew.ALL, dew.Audit(sink))
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	// queries are not audited, even with a middleware registered for them
+	testRunQuery(t, ctx, &findUser{ID: 1})
+
+	if len(sink.entries) != 1 || sink.entries[0].Command != "createUser" {
+		t.Fatalf("unexpected entries: %v", sink.entries)
+	}
+}
@@ -0,0 +1,139 @@
+package dew
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBatcherClosed is returned when adding an action to a closed batcher.
+	ErrBatcherClosed = errors.New("batcher closed")
+)
+
+// ActionBatcher buffers actions and dispatches them with DispatchMulti
+// when the count threshold or the time interval is reached.
+type ActionBatcher struct {
+	ctx      context.Context
+	maxCount int
+	maxWait  time.Duration
+	clock    Clock
+	onError  func(err error)
+
+	// flushMu serializes the flushes, so batches are dispatched in order.
+	flushMu sync.Mutex
+
+	mu      sync.Mutex
+	pending []CommandHandler[Action]
+	timer   Timer
+	// gen identifies the buffered batch, so a timer firing after its batch was taken does nothing.
+	gen    uint64
+	closed bool
+}
+
+// BatcherOption configures an ActionBatcher.
+type BatcherOption func(b *ActionBatcher)
+
+// WithBatchClock sets the clock used for the time interval, instead of the clock of the bus.
+func WithBatchClock(clock Clock) BatcherOption {
+	return func(b *ActionBatcher) {
+		b.clock = clock
+	}
+}
+
+// WithBatchErrorHandler sets the function called with the error of a flush triggered by the time interval.
+func WithBatchErrorHandler(fn func(err error)) BatcherOption {
+	return func(b *ActionBatcher) {
+		b.onError = fn
+	}
+}
+
+// Batcher creates an ActionBatcher dispatching to the bus.
+// Accumulated actions are flushed when maxCount actions are buffered
+// or maxWait has elapsed since the first buffered action.
+// A zero maxCount or maxWait disables the corresponding threshold.
+func Batcher(bus Bus, maxCount int, maxWait time.Duration, opts ...BatcherOption) *ActionBatcher {
+	b := &ActionBatcher{
+		ctx:      NewContext(context.Background(), bus),
+		maxCount: maxCount,
+		maxWait:  maxWait,
+		clock:    bus.(*mux).clockOf(),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Add buffers the action. If the count threshold is reached,
+// the batch is flushed and the dispatch error is returned.
+func (b *ActionBatcher) Add(action CommandHandler[Action]) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBatcherClosed
+	}
+	b.pending = append(b.pending, action)
+	if b.maxCount > 0 && len(b.pending) >= b.maxCount {
+		b.mu.Unlock()
+		return b.flush(b.take)
+	}
+	if len(b.pending) == 1 && b.maxWait > 0 {
+		gen := b.gen
+		b.timer = b.clock.AfterFunc(b.maxWait, func() { b.flushOnTimer(gen) })
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// Flush dispatches the buffered actions immediately.
+func (b *ActionBatcher) Flush() error {
+	return b.flush(b.take)
+}
+
+// Close flushes the buffered actions and stops accepting new ones.
+func (b *ActionBatcher) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	return b.flush(b.take)
+}
+
+// flush dispatches the batch returned by take. It takes the lock to call take and releases it
+// before dispatching. Flushes are serialized, so the batches are dispatched in the order they are taken.
+func (b *ActionBatcher) flush(take func() []CommandHandler[Action]) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	b.mu.Lock()
+	batch := take()
+	b.mu.Unlock()
+	return DispatchMulti(b.ctx, batch...)
+}
+
+// take removes the buffered actions and stops the timer. It must be called with the lock held.
+func (b *ActionBatcher) take() []CommandHandler[Action] {
+	if b.timer != nil {
+		// The timer may have fired already: gen makes its flush a no-op.
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.gen++
+	batch := b.pending
+	b.pending = nil
+	return batch
+}
+
+// flushOnTimer flushes the batch gen when its time interval has elapsed,
+// unless it has already been flushed.
+func (b *ActionBatcher) flushOnTimer(gen uint64) {
+	err := b.flush(func() []CommandHandler[Action] {
+		if b.gen != gen {
+			return nil
+		}
+		return b.take()
+	})
+	if err != nil && b.onError != nil {
+		b.onError(err)
+	}
+}
@@ -0,0 +1,141 @@
+package dew_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+func newBatchMux(dispatches *[]int) dew.Bus {
+	mux := dew.New()
+	mux.UseDispatch(func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			*dispatches = append(*dispatches, 0)
+			return next.Handle(ctx)
+		})
+	})
+	mux.Use(dew.ACTION, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			(*dispatches)[len(*dispatches)-1]++
+			return next.Handle(ctx)
+		})
+	})
+	mux.Register(new(userHandler))
+	return mux
+}
+
+func TestBatcher_FlushOnCount(t *testing.T) {
+	var dispatches []int
+	clock := new(dewtest.FakeClock)
+	b := dew.Batcher(newBatchMux(&dispatches), 3, time.Minute, dew.WithBatchClock(clock))
+
+	for i := 0; i < 7; i++ {
+		if err := b.Add(dew.NewAction(&createUser{Name: "john"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(dispatches) != 2 || dispatches[0] != 3 || dispatches[1] != 3 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dispatches) != 3 || dispatches[2] != 1 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+	if err := b.Add(dew.NewAction(&createUser{Name: "john"})); !errors.Is(err, dew.ErrBatcherClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBatcher_FlushOnTime(t *testing.T) {
+	var dispatches []int
+	var flushErr error
+	clock := new(dewtest.FakeClock)
+	b := dew.Batcher(newBatchMux(&dispatches), 10, time.Second,
+		dew.WithBatchClock(clock),
+		dew.WithBatchErrorHandler(func(err error) { flushErr = err }),
+	)
+
+	_ = b.Add(dew.NewAction(&createUser{Name: "john"}))
+	clock.Advance(500 * time.Millisecond)
+	_ = b.Add(dew.NewAction(&createUser{Name: "jane"}))
+	if len(dispatches) != 0 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if len(dispatches) != 1 || dispatches[0] != 2 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+
+	// errors of timed flushes are reported to the error handler
+	_ = b.Add(dew.NewAction(&createUser{Name: ""}))
+	clock.Advance(time.Second)
+	if !errors.Is(flushErr, errNameRequired) {
+		t.Fatalf("unexpected error: %v", flushErr)
+	}
+
+	// nothing left to flush
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dispatches) != 2 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+}
+
+func TestBatcher_Flush(t *testing.T) {
+	var dispatches []int
+	b := dew.Batcher(newBatchMux(&dispatches), 0, 0)
+
+	_ = b.Add(dew.NewAction(&createUser{Name: "john"}))
+	_ = b.Add(dew.NewAction(&createUser{Name: "jane"}))
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dispatches) != 1 || dispatches[0] != 2 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+}
+
+// firedClock is a dew.Clock whose timers have always fired when stopped,
+// so the test can run their function late, as a timer racing with a flush does.
+type firedClock struct {
+	dewtest.FakeClock
+	fns []func()
+}
+
+func (c *firedClock) AfterFunc(_ time.Duration, f func()) dew.Timer {
+	c.fns = append(c.fns, f)
+	return firedTimer{}
+}
+
+type firedTimer struct{}
+
+func (firedTimer) Stop() bool { return false }
+
+func TestBatcher_StaleTimer(t *testing.T) {
+	var dispatches []int
+	clock := new(firedClock)
+	b := dew.Batcher(newBatchMux(&dispatches), 2, time.Second, dew.WithBatchClock(clock))
+
+	_ = b.Add(dew.NewAction(&createUser{Name: "john"}))
+	_ = b.Add(dew.NewAction(&createUser{Name: "jane"}))
+	_ = b.Add(dew.NewAction(&createUser{Name: "jack"}))
+
+	// the timer of the first batch fires after the batch was flushed on count
+	clock.fns[0]()
+	if len(dispatches) != 1 || dispatches[0] != 2 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+
+	clock.fns[1]()
+	if len(dispatches) != 2 || dispatches[1] != 1 {
+		t.Fatalf("unexpected dispatches: %v", dispatches)
+	}
+}
@@ -0,0 +1,57 @@
+package dew
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultMaxBreadcrumbs is the number of breadcrumbs kept by WithBreadcrumbs if max is not positive.
+const DefaultMaxBreadcrumbs = 64
+
+type breadcrumbsKey struct{}
+
+// breadcrumbTrail records the names of the commands handled, keeping the most recent ones.
+type breadcrumbTrail struct {
+	max int
+
+	mu    sync.Mutex
+	names []string
+}
+
+func (b *breadcrumbTrail) add(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.names) == b.max {
+		b.names = b.names[1:]
+	}
+	b.names = append(b.names, name)
+}
+
+// WithBreadcrumbs returns a new context recording the type names of the commands handled with it,
+// including re-entrant commands and queries executed by QueryAsync, so they can be included in
+// error reports. Only the max most recent names are kept, or DefaultMaxBreadcrumbs if max is not
+// positive. The trail can be read with Breadcrumbs by the handlers and by the caller once the
+// commands have returned:
+//
+//	ctx = dew.WithBreadcrumbs(ctx, 0)
+//	if _, err := dew.Dispatch(ctx, action); err != nil {
+//		log.Printf("%v (trail: %v)", err, dew.Breadcrumbs(ctx))
+//	}
+func WithBreadcrumbs(ctx context.Context, max int) context.Context {
+	if max <= 0 {
+		max = DefaultMaxBreadcrumbs
+	}
+	return context.WithValue(ctx, breadcrumbsKey{}, &breadcrumbTrail{max: max})
+}
+
+// Breadcrumbs returns the type names of the commands handled so far on a context created with
+// WithBreadcrumbs, in the order they started. It returns nil if breadcrumbs are not enabled on the context.
+func Breadcrumbs(ctx context.Context) []string {
+	b, ok := ctx.Value(breadcrumbsKey{}).(*breadcrumbTrail)
+	if !ok {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.names...)
+}
@@ -0,0 +1,63 @@
+package dew_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestBreadcrumbs(t *testing.T) {
+	type findUserPost struct {
+		ID     int
+		Result []string
+	}
+
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	mux.Register(dew.HandlerFunc[findUserPost](
+		func(ctx context.Context, query *findUserPost) error {
+			if _, err := dew.Query(ctx, &findUser{ID: query.ID}); err != nil {
+				return err
+			}
+			if err := dew.QueryAsync(ctx, dew.NewQuery(&findPost{ID: 1}), dew.NewQuery(&findPost{ID: 2})); err != nil {
+				return err
+			}
+			query.Result = dew.Breadcrumbs(ctx)
+			return nil
+		},
+	))
+	ctx := dew.WithBreadcrumbs(dew.NewContext(context.Background(), mux), 0)
+
+	result := testRunQuery(t, ctx, &findUserPost{ID: 1})
+	want := []string{"findUserPost", "findUser", "findPost", "findPost"}
+	if !reflect.DeepEqual(result.Result, want) {
+		t.Fatalf("unexpected breadcrumbs: %v", result.Result)
+	}
+
+	// the caller reads the trail once the command has returned, e.g. for an error report
+	if _, err := dew.Query(ctx, &findUser{ID: 2}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := dew.Breadcrumbs(ctx); !reflect.DeepEqual(got, append(want, "findUser")) {
+		t.Fatalf("unexpected breadcrumbs: %v", got)
+	}
+
+	// only the most recent breadcrumbs are kept
+	ctx = dew.WithBreadcrumbs(dew.NewContext(context.Background(), mux), 2)
+	testRunQuery(t, ctx, &findUserPost{ID: 1})
+	if got := dew.Breadcrumbs(ctx); !reflect.DeepEqual(got, []string{"findPost", "findPost"}) {
+		t.Fatalf("unexpected breadcrumbs: %v", got)
+	}
+
+	// breadcrumbs are not recorded unless enabled
+	ctx = dew.NewContext(context.Background(), mux)
+	if result := testRunQuery(t, ctx, &findUserPost{ID: 1}); result.Result != nil {
+		t.Fatalf("unexpected breadcrumbs: %v", result.Result)
+	}
+	if dew.Breadcrumbs(ctx) != nil {
+		t.Fatal("expected no breadcrumbs")
+	}
+}
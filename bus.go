@@ -2,6 +2,8 @@ package dew
 
 import (
 	"context"
+	"reflect"
+	"time"
 )
 
 // Bus contains the core methods for dispatching commands.
@@ -11,20 +13,99 @@ type Bus interface {
 	//
 	//	func (h *Handler) FooMethod(ctx context.Context, command *BarCommand) error
 	Register(handler any)
+	// Unregister removes the handlers of the command type of the sample, e.g. CreateUser{}.
+	// It reports whether a handler was removed.
+	Unregister(command any) bool
+	// UnregisterType removes the handlers of the command type.
+	// It reports whether a handler was removed.
+	UnregisterType(t reflect.Type) bool
+	// OnRegister adds a callback called whenever a handler is registered to the bus or any of its groups.
+	OnRegister(fn func(cmdType reflect.Type, op OpType, module Bus))
+	// OnUnhandled adds a callback called whenever a command without a handler is dispatched.
+	OnUnhandled(fn func(cmdType reflect.Type, op OpType))
+	// OnFirstUse adds a callback called the first time a command type is resolved,
+	// with the time taken to resolve its handler and build its middleware chain.
+	OnFirstUse(fn func(cmdType reflect.Type, buildDur time.Duration))
+	// RegisterChecked registers the handler, or returns an error listing the skipped methods
+	// if the handler has no handler method, or naming both handlers if it handles an already
+	// registered command type.
+	RegisterChecked(handler any) error
+	// RegisterMany registers each handler and returns the aggregated registration errors.
+	// Handlers conflicting with an already registered command type are skipped.
+	RegisterMany(handlers ...any) error
+	// LoadRoutes registers the handlers bound by the route table, without looking up handler methods.
+	// Nothing is registered if any route is invalid or conflicts with a registered command type.
+	LoadRoutes(routes []Route) error
 	// Use appends the middlewares to the mux middleware chain.
 	// The middleware chain will be executed in the order they were added.
 	// These middlewares are executed per command instead of per dispatch / query.
 	Use(op OpType, middlewares ...func(next Middleware) Middleware)
+	// UseFor appends the middlewares to the chain of the command type of the sample, e.g. FindUser{}.
+	// They are executed inside the middlewares added with Use.
+	UseFor(command any, middlewares ...func(next Middleware) Middleware)
+	// UseHandlerWrapper appends the wrappers to the handler wrapper chain.
+	// Wrappers are executed immediately around the handler, inside all other middlewares.
+	UseHandlerWrapper(op OpType, wrappers ...func(next Middleware) Middleware)
+	// OnDispatch adds an observer called after each execution of a command, with the command,
+	// its operation type, duration and error. Observers cannot alter the execution.
+	OnDispatch(fn func(info DispatchEvent))
+	// OnError adds an observer called with each command whose execution failed and its error.
+	OnError(fn func(cmd Command, err error))
+	// RequireOrder records that the before middleware must run ahead of the after middleware.
+	RequireOrder(before, after func(next Middleware) Middleware)
+	// Verify checks that the middleware chains of the bus and its groups with handlers honor the ordering constraints
+	// and the limit of WithMaxMiddlewareDepth.
+	Verify() error
+	// SetReentryPolicy sets the policy consulted before each command issued by a handler,
+	// with the types of the commands being executed and the type of the issued command.
+	SetReentryPolicy(policy func(stack []reflect.Type, next reflect.Type) error)
+	// Close stops the bus from accepting new executions and waits for the executions in flight,
+	// or until the context is done.
+	Close(ctx context.Context) error
+	// Warmup builds the middleware chains of the bus and its groups with handlers ahead of the first dispatch.
+	Warmup()
+	// MiddlewareDepth returns the number of command middlewares and handler wrappers
+	// executed for the given operation type.
+	MiddlewareDepth(op OpType) int
+	// RuntimeStats returns the goroutine and context pool counters of the bus and its groups.
+	RuntimeStats() RuntimeStats
+	// LatencyStats returns the handler latency percentiles for the command type,
+	// recorded with WithLatencyTracking.
+	LatencyStats(cmdType reflect.Type) Percentiles
+	// GroupCount returns the number of groups created from the bus, including nested groups.
+	GroupCount() int
 	// Group creates a new mux with a copy of the parent middlewares.
 	Group(fn func(mx Bus)) Bus
+	// CleanGroup creates a new mux sharing the handler registry but starting
+	// without any of the parent command middlewares.
+	// Dispatch and query middlewares of the bus dispatching the command still apply.
+	CleanGroup(fn func(mx Bus)) Bus
+	// Handlers returns the handlers registered to the bus and its groups.
+	Handlers() []HandlerInfo
+	// HandlerSnapshot returns a snapshot of the handlers registered to the bus and its groups.
+	HandlerSnapshot() *HandlerSnapshot
+	// RestoreHandlers atomically replaces the registered handlers with the snapshot.
+	// In-flight dispatches are not affected.
+	RestoreHandlers(snapshot *HandlerSnapshot)
+	// ExportGraphviz returns a DOT graph of the registered command types,
+	// their handlers, and the middlewares each command passes through.
+	ExportGraphviz() string
 	// UseDispatch appends the middlewares to the dispatch middleware chain.
 	// Dispatch middlewares are executed only once per dispatch instead of per command.
 	UseDispatch(middlewares ...func(next Middleware) Middleware)
+	// UseQueryBatch appends the functions processing the batch of queries of QueryAsync.
+	// They run once per QueryAsync, after the query middlewares and before the queries are executed.
+	UseQueryBatch(fns ...QueryBatchFunc)
 	// UseQuery appends the middlewares to the query middleware chain.
 	// Query middlewares are executed only once per query instead of per command.
 	UseQuery(middlewares ...func(next Middleware) Middleware)
 }
 
+// HandlerSnapshot is an opaque snapshot of the registered handlers.
+type HandlerSnapshot struct {
+	entries map[handlerKey]*handler
+}
+
 type busKey struct{}
 
 // NewContext creates a new context with the given bus.
@@ -55,8 +136,13 @@ type Context interface {
 	WithContext(ctx context.Context) Context
 	// WithValue returns a new Context with the given key-value pair added to the context.
 	WithValue(key, val any) Context
+	// Set sets the value of the key, visible downstream through Context. Consecutive calls
+	// share a single context node, so setting several values allocates less than WithValue.
+	Set(key, val any)
 	// Command returns the command object to be processed.
 	Command() Command
+	// Op returns the operation type of the command being executed.
+	Op() OpType
 }
 
 // HandlerFunc defines a function type that takes a context and a command, returning an error.
@@ -66,3 +152,20 @@ type HandlerFunc[T any] func(ctx context.Context, command *T) error
 func (f HandlerFunc[T]) Handle(ctx context.Context, command *T) error {
 	return f(ctx, command)
 }
+
+// Adapt converts a function not written for the bus, returning the updated command,
+// into a handler. The returned command, if not nil, is copied onto the dispatched one:
+//
+//	bus.Register(dew.Adapt(users.CreateUser))
+func Adapt[T any](fn func(ctx context.Context, req *T) (*T, error)) HandlerFunc[T] {
+	return func(ctx context.Context, command *T) error {
+		result, err := fn(ctx, command)
+		if err != nil {
+			return err
+		}
+		if result != nil && result != command {
+			*command = *result
+		}
+		return nil
+	}
+}
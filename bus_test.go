@@ -40,3 +40,237 @@ func TestNewContext(t *testing.T) {
 		}
 	})
 }
+
+func TestHandlerSnapshot(t *testing.T) {
+	type fooQuery struct{ Result string }
+	type barQuery struct{ Result string }
+
+	bus := New()
+	bus.Register(HandlerFunc[fooQuery](func(ctx context.Context, q *fooQuery) error {
+		q.Result = "foo-v1"
+		return nil
+	}))
+	ctx := NewContext(context.Background(), bus)
+
+	// warm up the cache before taking the snapshot
+	if _, err := Query(ctx, &fooQuery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := bus.HandlerSnapshot()
+
+	bus.Group(func(bus Bus) {
+		bus.Register(HandlerFunc[fooQuery](func(ctx context.Context, q *fooQuery) error {
+			q.Result = "foo-v2"
+			return nil
+		}))
+		bus.Register(HandlerFunc[barQuery](func(ctx context.Context, q *barQuery) error {
+			q.Result = "bar"
+			return nil
+		}))
+	})
+
+	if _, err := Query(ctx, &barQuery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	bus.RestoreHandlers(snapshot)
+
+	foo, err := Query(ctx, &fooQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foo.Result != "foo-v1" {
+		t.Errorf("expected restored handler, got: %s", foo.Result)
+	}
+	if _, err := Query(ctx, &barQuery{}); err == nil {
+		t.Error("expected handler not found after restore, got nil")
+	}
+}
+
+func TestOpNameKey(t *testing.T) {
+	var fromMiddleware, fromHandler any
+
+	bus := New(WithOpNameKey())
+	bus.Use(ALL, func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			fromMiddleware = ctx.Context().Value(OpNameKey)
+			return next.Handle(ctx)
+		})
+	})
+	bus.Register(HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error {
+		fromHandler = ctx.Value(OpNameKey)
+		return nil
+	}))
+	ctx := NewContext(context.Background(), bus)
+
+	if _, err := Dispatch(ctx, &createUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if fromMiddleware != "createUser" {
+		t.Errorf("unexpected op name in middleware: %v", fromMiddleware)
+	}
+	if fromHandler != "createUser" {
+		t.Errorf("unexpected op name in handler: %v", fromHandler)
+	}
+
+	// the key is not set by default
+	fromHandler = nil
+	ctx = NewContext(context.Background(), New())
+	MustFromContext(ctx).Register(HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error {
+		fromHandler = ctx.Value(OpNameKey)
+		return nil
+	}))
+	if _, err := Dispatch(ctx, &createUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if fromHandler != nil {
+		t.Errorf("unexpected op name in handler: %v", fromHandler)
+	}
+}
+
+type createUser struct{}
+
+func (createUser) Validate(context.Context) error { return nil }
+
+func TestWarmup(t *testing.T) {
+	bus := New()
+	bus.Use(ALL, func(next Middleware) Middleware { return next })
+	group := bus.Group(func(bus Bus) {
+		bus.Register(HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error { return nil }))
+	})
+	clean := bus.CleanGroup(func(bus Bus) {
+		bus.Register(HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error { return nil }))
+	})
+
+	bus.Warmup()
+
+	for _, mx := range []*mux{bus.(*mux), group.(*mux), clean.(*mux)} {
+		if mx.handlerFor(ACTION) == nil || mx.handlerFor(QUERY) == nil {
+			t.Errorf("expected the command chains to be built")
+		}
+		if mx.mHandlers[mDispatch] == nil || mx.mHandlers[mQuery] == nil {
+			t.Errorf("expected the dispatch chains to be built")
+		}
+	}
+
+	ctx := NewContext(context.Background(), bus)
+	if _, err := Dispatch(ctx, &createUser{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkWarmup(b *testing.B) {
+	newBus := func() Bus {
+		bus := New()
+		bus.Use(ALL, func(next Middleware) Middleware { return next })
+		bus.Group(func(bus Bus) {
+			bus.Use(ALL, func(next Middleware) Middleware { return next })
+			bus.Register(HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error { return nil }))
+		})
+		return bus
+	}
+
+	for _, warmup := range []bool{false, true} {
+		name := "first-call"
+		if warmup {
+			name = "first-call-after-warmup"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				bus := newBus()
+				if warmup {
+					bus.Warmup()
+				}
+				ctx := NewContext(context.Background(), bus)
+				b.StartTimer()
+
+				_, _ = Dispatch(ctx, &createUser{})
+			}
+		})
+	}
+}
+
+type valueKey int
+
+func TestBusContext_Set(t *testing.T) {
+	var fromHandler, sealed []any
+
+	bus := New(WithOpNameKey())
+	bus.Use(ALL, func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			ctx.Set(valueKey(1), "a")
+			ctx.Set(valueKey(2), "b")
+			return next.Handle(ctx)
+		})
+	}, func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			retained := ctx.Context()
+			ctx.Set(valueKey(2), "c")
+			ctx.Set(valueKey(3), "d")
+			// values set after the context was retained are not visible through it
+			sealed = []any{retained.Value(valueKey(2)), retained.Value(valueKey(3))}
+			return next.Handle(ctx)
+		})
+	})
+	bus.Register(HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error {
+		fromHandler = []any{ctx.Value(valueKey(1)), ctx.Value(valueKey(2)), ctx.Value(valueKey(3)), ctx.Value(OpNameKey)}
+		return nil
+	}))
+	ctx := NewContext(context.Background(), bus)
+
+	if _, err := Dispatch(ctx, &createUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fromHandler) != 4 || fromHandler[0] != "a" || fromHandler[1] != "c" || fromHandler[2] != "d" || fromHandler[3] != "createUser" {
+		t.Errorf("unexpected values in handler: %v", fromHandler)
+	}
+	if sealed[0] != "b" || sealed[1] != nil {
+		t.Errorf("unexpected values in retained context: %v", sealed)
+	}
+}
+
+func TestBusContext_SetAllocs(t *testing.T) {
+	set := testing.AllocsPerRun(100, func() {
+		c := &BusContext{ctx: context.Background()}
+		for i := 0; i < 8; i++ {
+			c.Set(valueKey(i), i)
+		}
+		_ = c.Context()
+	})
+	withValue := testing.AllocsPerRun(100, func() {
+		c := &BusContext{ctx: context.Background()}
+		for i := 0; i < 8; i++ {
+			c.WithValue(valueKey(i), i)
+		}
+		_ = c.Context()
+	})
+	if set >= withValue {
+		t.Errorf("expected Set to allocate less than WithValue: %v >= %v", set, withValue)
+	}
+}
+
+func BenchmarkBusContext_Values(b *testing.B) {
+	b.Run("WithValue", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c := &BusContext{ctx: context.Background()}
+			for k := 0; k < 8; k++ {
+				c.WithValue(valueKey(k), k)
+			}
+			_ = c.Context().Value(valueKey(0))
+		}
+	})
+	b.Run("Set", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c := &BusContext{ctx: context.Background()}
+			for k := 0; k < 8; k++ {
+				c.Set(valueKey(k), k)
+			}
+			_ = c.Context().Value(valueKey(0))
+		}
+	})
+}
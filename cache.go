@@ -0,0 +1,153 @@
+package dew
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// cacheKey identifies a cached query by type and key.
+type cacheKey struct {
+	t   reflect.Type
+	key string
+}
+
+type cacheEntry struct {
+	result  reflect.Value
+	expires time.Time
+}
+
+// queryCache holds the cached query results until they expire.
+type queryCache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+func (c *queryCache) load(key cacheKey, now time.Time) (reflect.Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	if !now.Before(e.expires) {
+		delete(c.entries, key)
+		return reflect.Value{}, false
+	}
+	return e.result, true
+}
+
+func (c *queryCache) store(key cacheKey, result reflect.Value, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{result: result, expires: expires}
+}
+
+// CacheMiddleware returns a query middleware caching the results of queries for ttl.
+// Queries are keyed by type and by keyFn, or by CommandKey if keyFn is nil; queries with
+// an empty key, or without a key derived by CommandKey, are not cached. On a hit, the handler is not executed and the query receives
+// a deep copy of the cached query. On a miss, a deep copy of the query is cached once the
+// handler succeeds. Actions are never cached.
+//
+// The cache is safe for concurrent use, e.g. by QueryAsync. Concurrent misses for the same key
+// all execute the handler. The middleware needs the command, so add it with Use:
+//
+//	bus.Use(dew.QUERY, dew.CacheMiddleware(time.Minute, nil))
+func CacheMiddleware(ttl time.Duration, keyFn func(Command) string) func(next Middleware) Middleware {
+	if keyFn == nil {
+		keyFn = func(cmd Command) string {
+			key, _ := CommandKey(cmd)
+			return key
+		}
+	}
+	c := &queryCache{entries: make(map[cacheKey]cacheEntry)}
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			query := ctx.Command()
+			if _, ok := query.(Action); ok || query == nil || ttl <= 0 {
+				return next.Handle(ctx)
+			}
+			v := reflect.ValueOf(query)
+			if v.Kind() != reflect.Ptr || v.IsNil() {
+				return next.Handle(ctx)
+			}
+			k := keyFn(query)
+			if k == "" {
+				return next.Handle(ctx)
+			}
+			v = v.Elem()
+			key := cacheKey{t: v.Type(), key: k}
+			clock := clockFrom(ctx.Context())
+			if result, ok := c.load(key, clock.Now()); ok {
+				deepCopy(v, result)
+				return nil
+			}
+			if err := next.Handle(ctx); err != nil {
+				return err
+			}
+			result := reflect.New(v.Type()).Elem()
+			deepCopy(result, v)
+			c.store(key, result, clock.Now().Add(ttl))
+			return nil
+		})
+	}
+}
+
+// deepCopy sets dst to a copy of src not sharing pointers, slices, or maps with it.
+// Unexported fields are copied shallowly. Values with cycles are not supported.
+func deepCopy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		deepCopy(p.Elem(), src.Elem())
+		dst.Set(p)
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			e := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(e, iter.Value())
+			m.SetMapIndex(iter.Key(), e)
+		}
+		dst.Set(m)
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		e := reflect.New(src.Elem().Type()).Elem()
+		deepCopy(e, src.Elem())
+		dst.Set(e)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).IsExported() {
+				deepCopy(dst.Field(i), src.Field(i))
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
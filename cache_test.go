@@ -0,0 +1,134 @@
+package dew_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+type listFriends struct {
+	UserID int
+	Result []string
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	var calls atomic.Int32
+	clock := dewtest.NewFakeClock(time.Now())
+	mux := dew.New(dew.WithClock(clock))
+	mux.Use(dew.QUERY, dew.CacheMiddleware(50*time.Millisecond, func(cmd dew.Command) string {
+		q := cmd.(*listFriends)
+		if q.UserID == 0 {
+			return ""
+		}
+		return fmt.Sprint(q.UserID)
+	}))
+	mux.Register(dew.HandlerFunc[listFriends](
+		func(ctx context.Context, query *listFriends) error {
+			calls.Add(1)
+			query.Result = []string{fmt.Sprintf("friend of %d", query.UserID)}
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	first := testRunQuery(t, ctx, &listFriends{UserID: 1})
+	second := testRunQuery(t, ctx, &listFriends{UserID: 1})
+	if calls.Load() != 1 {
+		t.Fatalf("unexpected number of calls: %d", calls.Load())
+	}
+	if len(second.Result) != 1 || second.Result[0] != "friend of 1" {
+		t.Fatalf("unexpected result: %v", second.Result)
+	}
+
+	// the cached result is a deep copy
+	first.Result[0] = "changed"
+	second.Result[0] = "changed"
+	if third := testRunQuery(t, ctx, &listFriends{UserID: 1}); third.Result[0] != "friend of 1" {
+		t.Fatalf("unexpected result: %v", third.Result)
+	}
+
+	// other keys are not served from the cache
+	testRunQuery(t, ctx, &listFriends{UserID: 2})
+	if calls.Load() != 2 {
+		t.Fatalf("unexpected number of calls: %d", calls.Load())
+	}
+
+	// queries with an empty key are not cached
+	testRunQuery(t, ctx, &listFriends{})
+	testRunQuery(t, ctx, &listFriends{})
+	if calls.Load() != 4 {
+		t.Fatalf("unexpected number of calls: %d", calls.Load())
+	}
+
+	// results are served until they expire
+	clock.Advance(49 * time.Millisecond)
+	testRunQuery(t, ctx, &listFriends{UserID: 1})
+	if calls.Load() != 4 {
+		t.Fatalf("unexpected number of calls: %d", calls.Load())
+	}
+
+	// expired results are refreshed
+	clock.Advance(time.Millisecond)
+	testRunQuery(t, ctx, &listFriends{UserID: 1})
+	if calls.Load() != 5 {
+		t.Fatalf("unexpected number of calls: %d", calls.Load())
+	}
+}
+
+func TestCacheMiddleware_QueryAsync(t *testing.T) {
+	var calls atomic.Int32
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.CacheMiddleware(time.Minute, nil))
+	mux.Register(dew.HandlerFunc[listFriends](
+		func(ctx context.Context, query *listFriends) error {
+			calls.Add(1)
+			query.Result = []string{fmt.Sprint(query.UserID)}
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	queries := make([]*listFriends, 20)
+	commands := make([]dew.CommandHandler[dew.Command], len(queries))
+	for i := range queries {
+		queries[i] = &listFriends{UserID: i % 2}
+		commands[i] = dew.NewQuery(queries[i])
+	}
+	if err := dew.QueryAsync(ctx, commands...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, q := range queries {
+		if len(q.Result) != 1 || q.Result[0] != fmt.Sprint(i%2) {
+			t.Fatalf("unexpected result: %v", q.Result)
+		}
+	}
+
+	calls.Store(0)
+	testRunQuery(t, ctx, &listFriends{UserID: 1})
+	if calls.Load() != 0 {
+		t.Fatalf("unexpected number of calls: %d", calls.Load())
+	}
+}
+
+func TestCacheMiddleware_UnexportedFields(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.CacheMiddleware(time.Minute, nil))
+	mux.Register(dew.HandlerFunc[findItem](
+		func(ctx context.Context, query *findItem) error {
+			query.Result = fmt.Sprintf("item%d", query.id)
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// queries differing only in unexported state have no reliable key, so they are not cached
+	testRunQuery(t, ctx, &findItem{id: 1})
+	if result := testRunQuery(t, ctx, &findItem{id: 2}); result.Result != "item2" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
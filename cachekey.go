@@ -0,0 +1,82 @@
+package dew
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sync/atomic"
+)
+
+// Keyer is implemented by commands that provide their own key for caching and coalescing.
+type Keyer interface {
+	// CacheKey returns a key identifying the command.
+	CacheKey() string
+}
+
+// KeyFunc derives a stable key from a command. It reports false if no key can be derived.
+// Commands of different types must not share a key.
+type KeyFunc func(cmd Command) (string, bool)
+
+var keyFunc atomic.Value
+
+// SetKeyFunc sets the function deriving the keys of commands, used by CacheMiddleware
+// without a key function and available to other middlewares through CommandKey.
+// If fn is nil, DefaultKey is restored.
+func SetKeyFunc(fn KeyFunc) {
+	if fn == nil {
+		fn = DefaultKey
+	}
+	keyFunc.Store(fn)
+}
+
+// CommandKey returns the key of the command derived with the function set with SetKeyFunc.
+func CommandKey(cmd Command) (string, bool) {
+	if fn, ok := keyFunc.Load().(KeyFunc); ok {
+		return fn(cmd)
+	}
+	return DefaultKey(cmd)
+}
+
+// DefaultKey derives the key of the command from its CacheKey method, or a hash of its
+// fields. The key is prefixed by the command type. It reports false for a struct without
+// a CacheKey method whose fields are not all exported, or which has no fields.
+func DefaultKey(cmd Command) (string, bool) {
+	t := reflect.TypeOf(cmd)
+	if t == nil {
+		return "", false
+	}
+	if c, ok := cmd.(Keyer); ok {
+		return t.String() + ":" + c.CacheKey(), true
+	}
+	v := reflect.ValueOf(cmd)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	var data any = v.Interface()
+	if v.Kind() == reflect.Struct {
+		// The state in unexported fields cannot be hashed, so commands of a struct
+		// with unexported fields or without fields have no key.
+		if v.NumField() == 0 {
+			return "", false
+		}
+		fields := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				return "", false
+			}
+			fields[f.Name] = v.Field(i).Interface()
+		}
+		data = fields
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(b)
+	return t.String() + ":" + hex.EncodeToString(sum[:]), true
+}
@@ -0,0 +1,71 @@
+package dew_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type cachedReport struct {
+	Year int
+}
+
+func (q cachedReport) CacheKey() string { return "report" }
+
+type findItem struct {
+	id     int
+	Result string
+}
+
+func TestDefaultKey(t *testing.T) {
+	key := func(cmd dew.Command) string {
+		t.Helper()
+		k, ok := dew.DefaultKey(cmd)
+		if !ok {
+			t.Fatalf("expected a key for %#v", cmd)
+		}
+		return k
+	}
+
+	if key(&findUser{ID: 1}) != key(&findUser{ID: 1}) {
+		t.Fatal("expected equal commands to have the same key")
+	}
+	if key(&findUser{ID: 1}) == key(&findUser{ID: 2}) {
+		t.Fatal("expected commands with different fields to have different keys")
+	}
+	if key(&findUser{ID: 1}) == key(&findPost{ID: 1}) {
+		t.Fatal("expected commands of different types to have different keys")
+	}
+	if got := key(&cachedReport{Year: 2024}); got != "*dew_test.cachedReport:report" {
+		t.Fatalf("unexpected key: %s", got)
+	}
+	if got := key(&computeScore{UserID: 3}); strings.HasSuffix(got, ":3") {
+		t.Fatalf("expected the identity not to be used, got: %s", got)
+	}
+	if _, ok := dew.DefaultKey((*findUser)(nil)); ok {
+		t.Fatal("expected no key for a nil command")
+	}
+	if _, ok := dew.DefaultKey(&findItem{id: 1}); ok {
+		t.Fatal("expected no key for a command with unexported fields")
+	}
+	if _, ok := dew.DefaultKey(&struct{}{}); ok {
+		t.Fatal("expected no key for a command without fields")
+	}
+}
+
+func TestSetKeyFunc(t *testing.T) {
+	defer dew.SetKeyFunc(nil)
+
+	dew.SetKeyFunc(func(cmd dew.Command) (string, bool) {
+		return "constant", true
+	})
+	if k, _ := dew.CommandKey(&findUser{ID: 1}); k != "constant" {
+		t.Fatalf("unexpected key: %s", k)
+	}
+
+	dew.SetKeyFunc(nil)
+	if k, _ := dew.CommandKey(&findUser{ID: 1}); k == "constant" {
+		t.Fatal("expected the default key function to be restored")
+	}
+}
@@ -0,0 +1,70 @@
+package dew
+
+import (
+	"context"
+	"time"
+)
+
+// Clock provides the current time and timers, so time-based features can be tested deterministically.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel receiving the current time after the duration elapses.
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f in its own goroutine after the duration elapses.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer represents a single event created by Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the timer from firing.
+	// It returns false if the timer has already fired or been stopped.
+	Stop() bool
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// WithClock sets the clock of the built-in time-based features, such as the Retry delays,
+// the CacheMiddleware expiry, the Audit entry times, the WithLatencyTracking samples, and
+// the Batcher and OutboxDispatcher.Run intervals, e.g. to test them with dewtest.FakeClock.
+// The timeouts of SetTimeout, DispatchTimeout, and QueryTimeout rely on context deadlines
+// and always use the system clock.
+func WithClock(clock Clock) Option {
+	return func(mx *mux) {
+		mx.handlers.clock = clock
+	}
+}
+
+// clockOf returns the clock of the bus.
+func (mx *mux) clockOf() Clock {
+	if c := mx.handlers.clock; c != nil {
+		return c
+	}
+	return realClock{}
+}
+
+// busClock is the clock of a bus, following the WithClock option even if applied later.
+type busClock struct {
+	mx *mux
+}
+
+func (c busClock) Now() time.Time { return c.mx.clockOf().Now() }
+
+func (c busClock) After(d time.Duration) <-chan time.Time { return c.mx.clockOf().After(d) }
+
+func (c busClock) AfterFunc(d time.Duration, f func()) Timer { return c.mx.clockOf().AfterFunc(d, f) }
+
+// clockFrom returns the clock of the bus in the context, or the system clock if there is none.
+func clockFrom(ctx context.Context) Clock {
+	if bus, ok := FromContext(ctx); ok {
+		return bus.(*mux).clockOf()
+	}
+	return realClock{}
+}
@@ -0,0 +1,82 @@
+package dew
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// closer tracks the executions in flight to close the bus gracefully.
+type closer struct {
+	active atomic.Int64
+	closed atomic.Bool
+
+	// mu guards drained, the channel closed once the executions in flight have returned.
+	mu      sync.Mutex
+	drained chan struct{}
+}
+
+// begin records the start of an execution with the context. Once the bus is closed,
+// only the executions issued from executions in flight, including the detached ones,
+// are accepted. end must be called when the execution returns.
+func (mx *mux) begin(ctx context.Context) error {
+	c := &mx.handlers.closer
+	// Count the execution before checking the flag, so Close cannot miss it.
+	c.active.Add(1)
+	if c.closed.Load() && !isNested(ctx) {
+		c.end()
+		return ErrBusClosed
+	}
+	return nil
+}
+
+// end records the end of an execution started with begin.
+func (mx *mux) end() {
+	mx.handlers.closer.end()
+}
+
+// isNested reports whether the context is the context of an execution in flight.
+func isNested(ctx context.Context) bool {
+	if _, detached := ctx.(detachedContext); detached {
+		return true
+	}
+	_, nested := ctx.Value(requestKey{}).(*request)
+	return nested
+}
+
+func (c *closer) end() {
+	if c.active.Add(-1) == 0 && c.closed.Load() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.drained != nil {
+			close(c.drained)
+			c.drained = nil
+		}
+	}
+}
+
+// Close stops the bus from accepting new executions: Dispatch, Query, QueryAsync, and the other
+// functions return ErrBusClosed. Commands issued by the executions in flight are still executed.
+// Close blocks until the executions in flight return, or returns the context error if the context
+// is done first. As the groups share the handlers of the bus, closing a group closes the bus.
+func (mx *mux) Close(ctx context.Context) error {
+	c := &mx.handlers.closer
+	c.closed.Store(true)
+	c.mu.Lock()
+	if c.active.Load() == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.drained == nil {
+		c.drained = make(chan struct{})
+	}
+	drained := c.drained
+	c.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
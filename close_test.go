@@ -0,0 +1,86 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+)
+
+func TestMux_Close(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	if err := mux.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); !errors.Is(err, dew.ErrBusClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "john"}); !errors.Is(err, dew.ErrBusClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := dew.QueryAsync(ctx, dew.NewQuery(&findUser{ID: 1})); !errors.Is(err, dew.ErrBusClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done := make(chan error, 1)
+	dew.DispatchDetached(ctx, &createUser{Name: "john"}, func(err error) { done <- err })
+	if err := <-done; !errors.Is(err, dew.ErrBusClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMux_CloseWaitsInFlight(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(dew.HandlerFunc[findPost](
+		func(ctx context.Context, query *findPost) error {
+			started <- struct{}{}
+			<-release
+			// commands issued by executions in flight are still executed
+			_, err := dew.Query(ctx, &findUser{ID: 1})
+			return err
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	queryErr := make(chan error, 1)
+	go func() {
+		queryErr <- dew.QueryAsync(ctx, dew.NewQuery(&findPost{ID: 1}), dew.NewQuery(&findPost{ID: 2}))
+	}()
+	<-started
+	<-started
+
+	t.Run("Timeout", func(t *testing.T) {
+		cctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := mux.Close(cctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- mux.Close(context.Background())
+	}()
+	select {
+	case err := <-closed:
+		t.Fatalf("close returned before the queries: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-queryErr; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 	"unsafe"
 )
 
@@ -40,6 +41,7 @@ func NewAction[T Action](cmd *T) CommandHandler[T] {
 	return &command[T]{
 		cmd: cmd,
 		typ: typ,
+		op:  ACTION,
 	}
 }
 
@@ -50,18 +52,46 @@ func NewQuery[T QueryAction](cmd *T) CommandHandler[T] {
 	return &command[T]{
 		cmd: cmd,
 		typ: typ,
+		op:  QUERY,
 	}
 }
 
+// ResolveHandler returns the bus or group the command will be dispatched to,
+// without executing it.
+func ResolveHandler[T Command](bus Bus, cmd *T) (Bus, error) {
+	typ := typeFor[T]()
+	c := &command[T]{cmd: cmd, typ: typ, op: opTypeOf(typ)}
+	if err := c.Resolve(bus); err != nil {
+		return nil, err
+	}
+	return c.mux, nil
+}
+
 // command carries the necessary information to dispatch a command.
 type command[T Command] struct {
 	mux     *mux
 	cmd     *T
 	handler HandlerFunc[T]
 	typ     reflect.Type
+	op      OpType
 }
 
 func (c *command[T]) Handle(ctx Context) error {
+	if c.cmd == nil {
+		return fmt.Errorf("%w: %v", ErrNilCommand, c.typ)
+	}
+	if l, ok := c.mux.handlers.limits.Load(c.typ); ok {
+		l := l.(*limiter)
+		if err := l.acquire(ctx.Context()); err != nil {
+			return fmt.Errorf("%v: %w", c.typ, err)
+		}
+		defer l.release()
+	}
+	if bctx, ok := ctx.(*BusContext); ok && bctx.overrides != nil {
+		if h, ok := bctx.overrides[c.typ]; ok {
+			return h.(HandlerFunc[T])(ctx.Context(), c.cmd)
+		}
+	}
 	return c.handler(ctx.Context(), c.cmd)
 }
 
@@ -74,26 +104,106 @@ func (c *command[T]) Mux() *mux {
 }
 
 func (c *command[T]) Resolve(bus Bus) error {
-	mx := bus.(*mux)
+	return c.resolveIn(context.Background(), bus)
+}
 
-	h, mxx, ok := loadHandlerCache[T](c.typ, mx)
+// resolveIn resolves the handler of the command on the bus. A command without a registered
+// handler is resolved to its override in the context, if any, before the fallback handler.
+func (c *command[T]) resolveIn(ctx context.Context, bus Bus) error {
+	if c.cmd == nil {
+		return fmt.Errorf("%w: %v", ErrNilCommand, c.typ)
+	}
+
+	r := bus.(*mux).handlers
+	set := r.load()
+
+	cache := set.cacheFor(c.op)
+
+	h, mxx, ok := loadHandlerCache[T](c.typ, cache)
 	if ok {
 		c.handler = h
 		c.mux = mxx
 		return nil
 	}
 
-	entry, ok := mx.entries.Load(c.typ)
+	hh, ok := set.lookup(c.op, c.typ)
 	if ok {
-		hh := entry.(*handler)
+		start := time.Now()
 		hhh := convertInterface[HandlerFunc[T]](hh.handler)
-		storeCache[T](mx.cache, c.typ, hh.mux, hhh)
+		storeCache[T](cache, c.typ, hh.mux, hhh)
 		c.handler = hhh
 		c.mux = hh.mux
+		hh.mux.firstUse(set, c.op, c.typ, start)
+		return nil
+	}
+
+	if h, ok := overrideFor(ctx, c.typ); ok {
+		c.handler = h.(HandlerFunc[T])
+		c.mux = bus.(*mux).setupOverride()
 		return nil
 	}
 
-	return fmt.Errorf("handler not found for %v", c.typ)
+	if fallback := r.fallback; fallback != nil {
+		c.handler = func(ctx context.Context, cmd *T) error {
+			return fallback(ctx, cmd)
+		}
+		c.mux = bus.(*mux)
+		return nil
+	}
+
+	r.notifyUnhandled(c.typ, c.op)
+	return fmt.Errorf("%w for %v", ErrHandlerNotFound, c.typ)
+}
+
+// resolveFrom copies the handler resolved by another command of the same type and operation type.
+// It reports whether the other command could be reused.
+func (c *command[T]) resolveFrom(other any) bool {
+	o, ok := other.(*command[T])
+	if !ok || o.handler == nil || o.op != c.op || c.cmd == nil {
+		return false
+	}
+	c.handler = o.handler
+	c.mux = o.mux
+	return true
+}
+
+// batchResolver is implemented by commands that can share a resolved handler.
+type batchResolver interface {
+	resolveFrom(other any) bool
+}
+
+// resolveAll resolves the handlers for the given commands.
+// The handler is looked up once per distinct command type and reused for the remaining
+// commands of the same type, starting with the type of the previous command.
+func resolveAll[T Command](ctx context.Context, bus Bus, cmds []CommandHandler[T]) error {
+	if len(cmds) == 1 {
+		return resolve[T](ctx, bus, cmds[0])
+	}
+	var resolved []batchResolver
+	for _, cmd := range cmds {
+		br, ok := cmd.(batchResolver)
+		if ok && reuseResolved(br, resolved) {
+			continue
+		}
+		if err := resolve[T](ctx, bus, cmd); err != nil {
+			return err
+		}
+		if ok {
+			resolved = append(resolved, br)
+		}
+	}
+	return nil
+}
+
+// reuseResolved resolves the command with the handler of a resolved command of the same type,
+// looking at the most recently resolved ones first. It reports whether one was found.
+func reuseResolved(cmd batchResolver, resolved []batchResolver) bool {
+	for i := len(resolved) - 1; i >= 0; i-- {
+		if cmd.resolveFrom(resolved[i]) {
+			return true
+		}
+	}
+	return false
 }
 
 func convertInterface[T any](i any) T {
@@ -114,8 +224,8 @@ func storeCache[T Command](cache *syncMap, t reflect.Type, mx *mux, handlerFunc
 }
 
 // loadHandlerCache loads the handler from the cache.
-func loadHandlerCache[T Command](typ reflect.Type, mx *mux) (HandlerFunc[T], *mux, bool) {
-	if v, ok := mx.cache.load(typ); ok {
+func loadHandlerCache[T Command](typ reflect.Type, cache *syncMap) (HandlerFunc[T], *mux, bool) {
+	if v, ok := cache.load(typ); ok {
 		e := v.(entry)
 		return *(*HandlerFunc[T])(e.p), e.m, true
 	}
@@ -131,6 +241,15 @@ func typeFor[T any]() reflect.Type {
 type handler struct {
 	// handler is the function to call.
 	handler any
+	// result is the function returning the result of the query, for QueryResult, if any.
+	result any
 	// mux is the mux that the handler belongs to.
 	mux *mux
+	// name is the readable name of the handler.
+	name string
+	// op is the operation type the handler is registered for.
+	op OpType
+	// all is every handler registered for the command type in registration order,
+	// including this one.
+	all []*handler
 }
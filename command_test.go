@@ -0,0 +1,56 @@
+package dew
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type batchUser struct{}
+
+func (batchUser) Validate(context.Context) error { return nil }
+
+type batchPost struct{}
+
+func (batchPost) Validate(context.Context) error { return nil }
+
+// BenchmarkResolveAll compares resolveAll, reusing the handler resolved for a command type,
+// with resolving each command on its own.
+func BenchmarkResolveAll(b *testing.B) {
+	ctx := context.Background()
+	bus := New()
+	bus.Register(HandlerFunc[batchUser](func(ctx context.Context, cmd *batchUser) error { return nil }))
+	bus.Register(HandlerFunc[batchPost](func(ctx context.Context, cmd *batchPost) error { return nil }))
+
+	batches := map[string]func(i int) CommandHandler[Action]{
+		"one-type": func(i int) CommandHandler[Action] { return NewAction(&batchUser{}) },
+		"two-types": func(i int) CommandHandler[Action] {
+			if i%2 == 0 {
+				return NewAction(&batchUser{})
+			}
+			return NewAction(&batchPost{})
+		},
+	}
+	for name, newAction := range batches {
+		for _, size := range []int{2, 1000} {
+			actions := make([]CommandHandler[Action], size)
+			for i := range actions {
+				actions[i] = newAction(i)
+			}
+			b.Run(fmt.Sprintf("%s-%d/reuse", name, size), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_ = resolveAll(ctx, bus, actions)
+				}
+			})
+			b.Run(fmt.Sprintf("%s-%d/each", name, size), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					for _, action := range actions {
+						_ = resolve[Action](ctx, bus, action)
+					}
+				}
+			})
+		}
+	}
+}
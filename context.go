@@ -1,9 +1,26 @@
 package dew
 
-import "context"
+import (
+	"context"
+	"reflect"
+)
 
 var _ Context = (*BusContext)(nil)
 
+// OpNameKey is the context key holding the type name of the command being executed,
+// e.g. "CreateUser". With WithOpNameKey, it is set before the command middlewares and
+// the handler run, so logging middlewares can read it from the context.Context.
+var OpNameKey = &contextKey{"OpName"}
+
+// contextKey is a value for use with context.WithValue.
+type contextKey struct {
+	name string
+}
+
+func (k *contextKey) String() string {
+	return "dew context value " + k.name
+}
+
 // BusContext represents the context for a command execution.
 type BusContext struct {
 	ctx context.Context
@@ -13,6 +30,26 @@ type BusContext struct {
 
 	// handler is the wrapped handler function.
 	handler internalHandler
+
+	// op is the operation type of the command being executed.
+	op OpType
+
+	// reached is the deepest level of the command middleware chain entered.
+	reached int
+
+	// shortCircuitedBy is the name of the middleware that stopped the chain.
+	shortCircuitedBy string
+
+	// counter accumulates the number of commands issued per type.
+	counter *commandCounter
+	// breadcrumbs records the names of the commands handled, if enabled.
+	breadcrumbs *breadcrumbTrail
+
+	// overrides holds the request-scoped handler overrides by command type.
+	overrides map[reflect.Type]any
+
+	// request is the state shared by the commands of the top-level request.
+	request *request
 }
 
 type internalHandler interface {
@@ -28,6 +65,12 @@ func (c *BusContext) Command() Command {
 	return c.handler.Command()
 }
 
+// Op returns the operation type of the command being executed,
+// or zero outside the execution of a command, e.g. in a dispatch middleware.
+func (c *BusContext) Op() OpType {
+	return c.op
+}
+
 // WithContext returns a new Context with the given context.
 func (c *BusContext) WithContext(ctx context.Context) Context {
 	c.ctx = ctx
@@ -38,6 +81,13 @@ func (c *BusContext) Copy(a *BusContext) *BusContext {
 	c.ctx = a.ctx
 	c.mwsIdx = a.mwsIdx
 	c.handler = a.handler
+	c.op = a.op
+	c.reached = a.reached
+	c.shortCircuitedBy = a.shortCircuitedBy
+	c.counter = a.counter
+	c.breadcrumbs = a.breadcrumbs
+	c.overrides = a.overrides
+	c.request = a.request
 	return c
 }
 
@@ -45,15 +95,52 @@ func (c *BusContext) Reset() {
 	c.ctx = nil
 	c.mwsIdx = 0
 	c.handler = nil
+	c.op = 0
+	c.reached = 0
+	c.shortCircuitedBy = ""
+	c.counter = nil
+	c.breadcrumbs = nil
+	c.overrides = nil
+	c.request = nil
 }
 
 // Context returns the underlying context.Context.
 // If no context is set, it returns context.Background().
 func (c *BusContext) Context() context.Context {
+	if vc, ok := c.ctx.(*valuesContext); ok {
+		// The context may now be retained: later values go to a new node.
+		vc.sealed = true
+	}
 	return c.ctx
 }
 
 // WithValue returns a new Context with the given key-value pair added to the context.
 func (c *BusContext) WithValue(key, val any) Context {
-	return c.WithContext(context.WithValue(c.ctx, key, val))
+	return c.WithContext(context.WithValue(c.Context(), key, val))
+}
+
+// Set sets the value of the key, visible downstream through Context.
+// The values set between two calls to Context are staged in a single context node.
+func (c *BusContext) Set(key, val any) {
+	if vc, ok := c.ctx.(*valuesContext); ok && vc.owner == c && !vc.sealed {
+		vc.values[key] = val
+		return
+	}
+	c.ctx = &valuesContext{Context: c.ctx, owner: c, values: map[any]any{key: val}}
+}
+
+// valuesContext is a context.Context holding the values staged with Set.
+// Its values are only modified until it is sealed by a call to Context.
+type valuesContext struct {
+	context.Context
+	owner  *BusContext
+	sealed bool
+	values map[any]any
+}
+
+func (c *valuesContext) Value(key any) any {
+	if v, ok := c.values[key]; ok {
+		return v
+	}
+	return c.Context.Value(key)
 }
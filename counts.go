@@ -0,0 +1,44 @@
+package dew
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+type commandCountsKey struct{}
+
+// commandCounter counts the commands issued per type.
+type commandCounter struct {
+	mu     sync.Mutex
+	counts map[reflect.Type]int
+}
+
+func (c *commandCounter) add(t reflect.Type) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[t]++
+}
+
+// WithCommandCounts returns a new context that counts the commands dispatched with it.
+// The counts include re-entrant commands and queries executed by QueryAsync.
+func WithCommandCounts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, commandCountsKey{}, &commandCounter{counts: make(map[reflect.Type]int)})
+}
+
+// CommandCounts returns a copy of the number of commands issued per type
+// on a context created with WithCommandCounts.
+// It returns nil if command counting is not enabled on the context.
+func CommandCounts(ctx context.Context) map[reflect.Type]int {
+	c, ok := ctx.Value(commandCountsKey{}).(*commandCounter)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[reflect.Type]int, len(c.counts))
+	for t, n := range c.counts {
+		counts[t] = n
+	}
+	return counts
+}
@@ -0,0 +1,57 @@
+package dew_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestCommandCounts(t *testing.T) {
+	type findUserPost struct {
+		ID int
+	}
+
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	mux.Register(dew.HandlerFunc[findUserPost](
+		func(ctx context.Context, query *findUserPost) error {
+			if _, err := dew.Query(ctx, &findUser{ID: query.ID}); err != nil {
+				return err
+			}
+			_, err := dew.Query(ctx, &findPost{ID: query.ID})
+			return err
+		},
+	))
+
+	ctx := dew.WithCommandCounts(dew.NewContext(context.Background(), mux))
+
+	if _, err := dew.Query(ctx, &findUserPost{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dew.QueryAsync(ctx,
+		dew.NewQuery(&findUser{ID: 1}),
+		dew.NewQuery(&findPost{ID: 1}),
+		dew.NewQuery(&findPost{ID: 2}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+
+	counts := dew.CommandCounts(ctx)
+	expected := map[reflect.Type]int{
+		reflect.TypeOf(findUserPost{}): 1,
+		reflect.TypeOf(findUser{}):     2,
+		reflect.TypeOf(findPost{}):     3,
+		reflect.TypeOf(createUser{}):   1,
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+
+	if counts := dew.CommandCounts(context.Background()); counts != nil {
+		t.Fatalf("expected nil counts, got: %v", counts)
+	}
+}
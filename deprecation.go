@@ -0,0 +1,36 @@
+package dew
+
+import (
+	"log"
+	"reflect"
+	"sync"
+)
+
+// Deprecated is implemented by commands that are deprecated.
+type Deprecated interface {
+	// DeprecationNotice returns the message explaining the deprecation.
+	DeprecationNotice() string
+}
+
+// deprecationWarned records the command types whose deprecation notice has been emitted.
+var deprecationWarned sync.Map
+
+// WarnDeprecated returns a middleware that logs the notice of deprecated commands.
+// The notice is logged once per command type per process.
+// If logf is nil, log.Printf is used.
+func WarnDeprecated(logf func(format string, args ...any)) func(next Middleware) Middleware {
+	if logf == nil {
+		logf = log.Printf
+	}
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			if cmd, ok := ctx.Command().(Deprecated); ok {
+				typ := reflect.TypeOf(cmd)
+				if _, warned := deprecationWarned.LoadOrStore(typ, struct{}{}); !warned {
+					logf("dew: %v is deprecated: %s", typ.Elem(), cmd.DeprecationNotice())
+				}
+			}
+			return next.Handle(ctx)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package dew_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type legacyCreateUser struct {
+	Name string
+}
+
+func (legacyCreateUser) Validate(context.Context) error { return nil }
+
+func (legacyCreateUser) DeprecationNotice() string { return "use createUser instead" }
+
+func TestWarnDeprecated(t *testing.T) {
+	var notices []string
+
+	mux := dew.New()
+	mux.Use(dew.ALL, dew.WarnDeprecated(func(format string, args ...any) {
+		notices = append(notices, fmt.Sprintf(format, args...))
+	}))
+	mux.Register(dew.HandlerFunc[legacyCreateUser](
+		func(ctx context.Context, command *legacyCreateUser) error { return nil },
+	))
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	for i := 0; i < 3; i++ {
+		testRunDispatch(t, ctx, dew.NewAction(&legacyCreateUser{Name: "john"}))
+		testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	}
+
+	if len(notices) != 1 {
+		t.Fatalf("expected a single notice, got: %v", notices)
+	}
+	if !strings.Contains(notices[0], "legacyCreateUser") || !strings.Contains(notices[0], "use createUser instead") {
+		t.Fatalf("unexpected notice: %s", notices[0])
+	}
+}
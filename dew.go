@@ -2,14 +2,35 @@ package dew
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"reflect"
 	"sync"
+	"time"
 )
 
 var (
 	// ErrValidationFailed is returned when the command validation fails.
 	ErrValidationFailed = fmt.Errorf("validation failed")
+	// ErrMiddlewareDepthExceeded is returned by Verify when a middleware chain exceeds the maximum
+	// depth set with WithMaxMiddlewareDepth.
+	ErrMiddlewareDepthExceeded = fmt.Errorf("middleware depth exceeded")
+	// ErrDuplicateHandler is returned when a handler is registered for an already handled command type.
+	ErrDuplicateHandler = fmt.Errorf("duplicate handler")
+	// ErrBusNotInContext is returned when the context does not carry a bus.
+	// The context passed to Dispatch, Query, and the other functions must be created with
+	// NewContext or derived from the context received by a handler or middleware;
+	// a context created from scratch, e.g. for background work, loses the bus.
+	ErrBusNotInContext = fmt.Errorf("bus not found in context: create it with dew.NewContext or derive it from the handler context")
+	// ErrBusNotFound is an alias of ErrBusNotInContext.
+	ErrBusNotFound = ErrBusNotInContext
+	// ErrNoHandlerMethods is returned by RegisterChecked when the handler has no handler method.
+	ErrNoHandlerMethods = fmt.Errorf("no handler methods")
+	// ErrHandlerNotFound is returned when no handler is registered for the command type.
+	ErrHandlerNotFound = fmt.Errorf("handler not found")
+	// ErrNilCommand is returned when a nil command pointer is dispatched.
+	ErrNilCommand = fmt.Errorf("nil command")
+	// ErrBusClosed is returned when a command is executed on a closed bus.
+	ErrBusClosed = fmt.Errorf("bus closed")
 )
 
 // Dispatch executes the action.
@@ -17,41 +38,222 @@ func Dispatch[T Action](ctx context.Context, action *T) (*T, error) {
 	return action, DispatchMulti(ctx, NewAction(action))
 }
 
+// DispatchTo executes the action on the target bus, ignoring the bus in the context.
+// It is useful when several buses can handle the same action type.
+// Commands issued by the handler are also executed on the target bus.
+func DispatchTo[T Action](ctx context.Context, target Bus, action *T) (*T, error) {
+	return action, DispatchMulti(NewContext(ctx, target), NewAction(action))
+}
+
+// DispatchDetached executes the action in a new goroutine and calls done, if not nil,
+// with the result once it has completed. The action runs with a context carrying the
+// values of ctx, but not its deadline or cancellation, so it outlives the caller's request.
+func DispatchDetached[T Action](ctx context.Context, action *T, done func(err error)) {
+	// Track the action from now on, so that Close waits for it.
+	var err error
+	var mx *mux
+	if bus, ok := FromContext(ctx); ok {
+		mx = bus.(*mux)
+		err = mx.begin(ctx)
+	}
+	ctx = detachedContext{ctx}
+	go func() {
+		if err == nil {
+			err = DispatchMulti(ctx, NewAction(action))
+			if mx != nil {
+				mx.end()
+			}
+		}
+		if done != nil {
+			done(err)
+		}
+	}()
+}
+
+// detachedContext is a context.Context carrying the values of its parent
+// without being cancelled with it.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+// Do executes the action.
+func Do[T Action](ctx context.Context, action *T) error {
+	return DispatchMulti(ctx, NewAction(action))
+}
+
+// DoR executes the action and extracts its result with the given function.
+func DoR[T Action, R any](ctx context.Context, action *T, result func(*T) R) (R, error) {
+	if err := DispatchMulti(ctx, NewAction(action)); err != nil {
+		var zero R
+		return zero, err
+	}
+	return result(action), nil
+}
+
 // DispatchMulti executes all actions synchronously.
 // It assumes that all handlers have been registered to the same mux.
 func DispatchMulti(ctx context.Context, actions ...CommandHandler[Action]) error {
+	return dispatchMulti(ctx, true, false, actions)
+}
+
+// DispatchMultiCollect executes all actions synchronously like DispatchMulti, but does not
+// stop at the first failure: every action is validated and executed, and the failures are
+// returned as CommandError values, combined with errors.Join or the aggregator set with
+// WithErrorAggregator. The Index of a CommandError is the position of the failing action.
+func DispatchMultiCollect(ctx context.Context, actions ...CommandHandler[Action]) error {
+	return dispatchMulti(ctx, true, true, actions)
+}
+
+// DispatchCollect executes all actions synchronously like DispatchMulti, and returns the
+// executed commands in the order of the actions, e.g. to render the results of a batch.
+// Unlike DispatchMultiCollect, it stops at the first failure and returns no command.
+func DispatchCollect(ctx context.Context, actions ...CommandHandler[Action]) ([]Command, error) {
+	if err := DispatchMulti(ctx, actions...); err != nil {
+		return nil, err
+	}
+	results := make([]Command, len(actions))
+	for i, action := range actions {
+		results[i] = action.Command()
+	}
+	return results, nil
+}
+
+// DispatchMultiNoValidate executes all actions synchronously without calling Validate.
+// Middlewares and handlers are still executed.
+// Use it only for trusted actions that have already been validated:
+// handlers receive the actions as-is, so invalid input is no longer rejected by the bus.
+func DispatchMultiNoValidate(ctx context.Context, actions ...CommandHandler[Action]) error {
+	return dispatchMulti(ctx, false, false, actions)
+}
+
+// dispatchMulti executes the actions, validating them first if validate is set.
+// If collect is set, it executes all actions and aggregates the failures
+// instead of returning the first one.
+func dispatchMulti(ctx context.Context, validate, collect bool, actions []CommandHandler[Action]) error {
 	if len(actions) == 0 {
 		return nil
 	}
 
 	bus, ok := FromContext(ctx)
 	if !ok {
-		return errors.New("bus not found in context")
+		return ErrBusNotInContext
+	}
+
+	if err := resolveAll(ctx, bus, actions); err != nil {
+		return err
 	}
 
+	mux := bus.(*mux)
+	if err := mux.begin(ctx); err != nil {
+		return err
+	}
+	defer mux.end()
+
+	rctx := mux.newContext(ctx)
+
+	defer mux.release(rctx)
+
+	// The closures of the common cases only capture the actions, to keep their allocation small.
+	var fn mHandlerFunc
+	switch {
+	case collect:
+		fn = func(ctx Context) error {
+			return dispatchCollect(ctx, mux, validate, actions)
+		}
+	case validate:
+		fn = func(ctx Context) error {
+			return dispatchActions(ctx, true, actions)
+		}
+	default:
+		fn = func(ctx Context) error {
+			return dispatchActions(ctx, false, actions)
+		}
+	}
+	return mux.mHandlers[mDispatch](rctx, fn)
+}
+
+// dispatchActions executes the actions in order, stopping at the first failure.
+func dispatchActions(ctx Context, validate bool, actions []CommandHandler[Action]) error {
 	for _, action := range actions {
-		if err := action.Resolve(bus); err != nil {
+		if err := dispatchAction(ctx, validate, action); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// dispatchCollect executes all the actions and aggregates their failures.
+func dispatchCollect(ctx Context, mux *mux, validate bool, actions []CommandHandler[Action]) error {
+	var errs []error
+	for i, action := range actions {
+		if err := dispatchAction(ctx, validate, action); err != nil {
+			errs = append(errs, &CommandError{Type: reflect.TypeOf(action.Command()).Elem(), Index: i, Err: err})
+		}
+	}
+	return mux.aggregateErrors(errs)
+}
+
+// dispatchAction validates the action if validate is set, and executes it.
+func dispatchAction(ctx Context, validate bool, action CommandHandler[Action]) error {
+	normalize(action)
+	if validate {
+		if err := validateAction(ctx.Context(), action.Command().(Action)); err != nil {
+			action.Mux().handlers.reportError(action.Command(), err)
+			return err
+		}
+	}
+	return action.Mux().dispatch(ACTION, ctx, action)
+}
+
+// normalize calls the normalizers registered for the action.
+func normalize(action CommandHandler[Action]) {
+	cmd := action.Command()
+	action.Mux().handlers.normalize(reflect.TypeOf(cmd).Elem(), cmd)
+}
+
+// DispatchAsync executes all actions concurrently and collects errors.
+// All actions are validated before any of them is executed. Dispatch middlewares
+// are executed once for the whole set of actions, command middlewares once per action.
+// It assumes that all handlers have been registered to the same mux.
+func DispatchAsync(ctx context.Context, actions ...CommandHandler[Action]) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	bus, ok := FromContext(ctx)
+	if !ok {
+		return ErrBusNotInContext
+	}
+
+	if err := resolveAll(ctx, bus, actions); err != nil {
+		return err
+	}
 
 	mux := bus.(*mux)
-	rctx := mux.pool.Get().(*BusContext)
-	rctx.Reset()
-	rctx.ctx = context.WithValue(ctx, busKey{}, mux)
+	if err := mux.begin(ctx); err != nil {
+		return err
+	}
+	defer mux.end()
+
+	rctx := mux.newContext(ctx) // Get a context from the pool.
 
-	defer mux.pool.Put(rctx)
+	defer mux.release(rctx) // Ensure the context is put back into the pool.
 
 	return mux.mHandlers[mDispatch](rctx, func(ctx Context) error {
 		for _, action := range actions {
-			if err := action.Command().(Action).Validate(ctx.Context()); err != nil {
-				return fmt.Errorf("%w: %v", ErrValidationFailed, err)
-			}
-			if err := action.Mux().dispatch(ACTION, ctx, action); err != nil {
+			normalize(action)
+			if err := validateAction(ctx.Context(), action.Command().(Action)); err != nil {
+				mux.handlers.reportError(action.Command(), err)
 				return err
 			}
 		}
-		return nil
+		return runAsync(mux, ctx, actions, 0, func(ctx Context, action CommandHandler[Action]) error {
+			return action.Mux().dispatch(ACTION, ctx, action)
+		})
 	})
 }
 
@@ -59,94 +261,296 @@ func DispatchMulti(ctx context.Context, actions ...CommandHandler[Action]) error
 func Query[T QueryAction](ctx context.Context, query *T) (*T, error) {
 	bus, ok := FromContext(ctx)
 	if !ok {
-		return nil, errors.New("bus not found in context")
+		return nil, ErrBusNotInContext
 	}
 
 	queryObj := NewQuery(query)
-	if err := queryObj.Resolve(bus); err != nil {
+	if err := resolve[T](ctx, bus, queryObj); err != nil {
 		return nil, err
 	}
 
-	mux := bus.(*mux)
-
-	rctx := mux.pool.Get().(*BusContext)
-	rctx.Reset()
-	rctx.ctx = context.WithValue(ctx, busKey{}, mux)
-
-	defer mux.pool.Put(rctx)
-
-	if err := mux.mHandlers[mQuery](rctx, func(ctx Context) error {
-		return queryObj.Mux().dispatch(QUERY, ctx, queryObj)
-	}); err != nil {
+	if err := runQuery[T](bus.(*mux), ctx, queryObj); err != nil {
 		return nil, err
 	}
 
 	return queryObj.Command().(*T), nil
 }
 
+// runQuery executes the resolved query on the mux.
+func runQuery[T Command](mux *mux, ctx context.Context, query CommandHandler[T]) error {
+	if err := mux.begin(ctx); err != nil {
+		return err
+	}
+	defer mux.end()
+
+	rctx := mux.newContext(ctx)
+
+	defer mux.release(rctx)
+
+	return mux.mHandlers[mQuery](rctx, func(ctx Context) error {
+		return query.Mux().dispatch(QUERY, ctx, query)
+	})
+}
+
+// QueryTo executes the query on the target bus, ignoring the bus in the context.
+// It is useful when several buses can handle the same query type.
+// Commands issued by the handler are also executed on the target bus.
+func QueryTo[T QueryAction](ctx context.Context, target Bus, query *T) (*T, error) {
+	return Query(NewContext(ctx, target), query)
+}
+
 // QueryAsync executes all queries asynchronously and collects errors.
 // It assumes that all handlers have been registered to the same mux.
 func QueryAsync(ctx context.Context, queries ...CommandHandler[Command]) error {
+	return QueryAsyncWithLimit(ctx, 0, queries...)
+}
+
+// QueryAsyncWithLimit executes all queries asynchronously like QueryAsync,
+// running at most limit queries at the same time. If limit is zero or negative,
+// the number of concurrent queries is unbounded.
+func QueryAsyncWithLimit(ctx context.Context, limit int, queries ...CommandHandler[Command]) error {
 	if len(queries) == 0 {
 		return nil
 	}
 	bus, ok := FromContext(ctx)
 	if !ok {
-		return errors.New("bus not found in context")
+		return ErrBusNotInContext
 	}
 
-	for _, query := range queries {
-		if err := query.Resolve(bus); err != nil {
-			return err
-		}
+	if err := resolveAll(ctx, bus, queries); err != nil {
+		return err
 	}
 
 	mux := bus.(*mux)
+	mux.lock.RLock()
+	batch := mux.queryBatch
+	mux.lock.RUnlock()
+	return mux.queryAsync(ctx, queries, batch, limit)
+}
 
-	rctx := mux.pool.Get().(*BusContext) // Get a context from the pool.
-	rctx.Reset()
-	rctx.ctx = context.WithValue(ctx, busKey{}, mux)
+// QueryAllFromHandler executes the queries concurrently from a handler, reusing the context
+// of the command being handled: the query middlewares, which already ran for the request,
+// are not executed again, while the command middlewares still apply to each query.
+// It collects the errors like QueryAsync. Outside of a handler, it behaves as QueryAsync.
+//
+//	func (h *OrderHandler) FindOrderDetails(ctx context.Context, q *FindOrderDetails) error {
+//		user, items := &FindUser{ID: q.UserID}, &FindOrderItems{ID: q.ID}
+//		return dew.QueryAllFromHandler(ctx, dew.NewQuery(user), dew.NewQuery(items))
+//	}
+func QueryAllFromHandler(ctx context.Context, queries ...CommandHandler[Command]) error {
+	if _, ok := ctx.Value(requestKey{}).(*request); !ok {
+		return QueryAsync(ctx, queries...)
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+	bus, ok := FromContext(ctx)
+	if !ok {
+		return ErrBusNotInContext
+	}
 
-	defer mux.pool.Put(rctx) // Ensure the context is put back into the pool.
+	if err := resolveAll(ctx, bus, queries); err != nil {
+		return err
+	}
 
-	return mux.mHandlers[mQuery](rctx, func(ctx Context) error {
-		// Create a goroutine for each query and synchronize with WaitGroup.
-		var wg sync.WaitGroup
-		errs := make(chan error, len(queries)) // Buffered channel to collect errors from goroutines.
-
-		for _, query := range queries {
-			query := query
-			wg.Add(1)
-			go func(query CommandHandler[Command]) {
-				defer wg.Done()
-				rctx := mux.pool.Get().(*BusContext) // Get a context from the pool.
-				rctx.Reset()
-				rctx.Copy(ctx.(*BusContext)) // Copy the context to the new context.
-
-				defer mux.pool.Put(rctx) // Ensure the context is put back into the pool.
-
-				if err := mux.mHandlers[mQuery](rctx, func(ctx Context) error {
-					return query.Mux().dispatch(QUERY, ctx, query)
-				}); err != nil {
-					errs <- err // Send errors to the channel.
+	mx := bus.(*mux)
+	rctx := mx.newContext(ctx) // Get a context from the pool, sharing the request.
+
+	defer mx.release(rctx) // Ensure the context is put back into the pool.
+
+	return runAsync(mx, rctx, queries, 0, func(ctx Context, query CommandHandler[Command]) error {
+		return query.Mux().dispatch(QUERY, ctx, query)
+	})
+}
+
+// queryAsync executes the resolved queries asynchronously and collects errors.
+// The batch functions are applied to the queries before they are executed.
+// At most limit queries run at the same time, unless limit is zero or negative.
+func (mx *mux) queryAsync(ctx context.Context, queries []CommandHandler[Command], batch []QueryBatchFunc, limit int) error {
+	if err := mx.begin(ctx); err != nil {
+		return err
+	}
+	defer mx.end()
+
+	rctx := mx.newContext(ctx) // Get a context from the pool.
+
+	defer mx.release(rctx) // Ensure the context is put back into the pool.
+
+	return mx.mHandlers[mQuery](rctx, func(ctx Context) error {
+		for _, fn := range batch {
+			var err error
+			if queries, err = fn(ctx, queries); err != nil {
+				return err
+			}
+		}
+
+		return runAsync(mx, ctx, queries, limit, func(ctx Context, query CommandHandler[Command]) error {
+			return mx.mHandlers[mQuery](ctx, func(ctx Context) error {
+				return query.Mux().dispatch(QUERY, ctx, query)
+			})
+		})
+	})
+}
+
+type asyncKey struct{}
+
+// IsAsync reports whether the context is the context of a command running concurrently
+// with others, i.e. executed by QueryAsync or DispatchAsync, or issued from such a command.
+func IsAsync(ctx context.Context) bool {
+	v, _ := ctx.Value(asyncKey{}).(bool)
+	return v
+}
+
+// runAsync runs each command with fn in its own goroutine and collects errors.
+// The commands run with a context cancelled when runAsync returns,
+// so no work started by a handler outlives the call.
+// With WithFailFast, the context is cancelled at the first error, and only that error is returned.
+// At most limit commands run at the same time, unless limit is zero or negative.
+func runAsync[T Command](mx *mux, ctx Context, cmds []CommandHandler[T], limit int, fn func(ctx Context, cmd CommandHandler[T]) error) error {
+	cctx, cancel := context.WithCancel(context.WithValue(ctx.Context(), asyncKey{}, true))
+	defer cancel()
+
+	// Create a goroutine for each command and synchronize with WaitGroup.
+	var wg sync.WaitGroup
+	errs := make(chan error, len(cmds)) // Buffered channel to collect errors from goroutines.
+
+	// First error, if failing fast.
+	var first error
+	var once sync.Once
+
+	// Semaphore bounding the number of running goroutines, if limited.
+	var sem chan struct{}
+	if limit > 0 && limit < len(cmds) {
+		sem = make(chan struct{}, limit)
+	}
+
+	skipped := false
+	for i, cmd := range cmds {
+		if mx.failFast {
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+				case <-cctx.Done():
 				}
-			}(query)
+			}
+			if cctx.Err() != nil {
+				// Skip the commands not started yet.
+				skipped = true
+				break
+			}
+		} else if sem != nil {
+			sem <- struct{}{}
 		}
+		wg.Add(1)
+		mx.handlers.stats.spawned.Add(1)
+		mx.handlers.stats.active.Add(1)
+		go func(i int, cmd CommandHandler[T]) {
+			defer wg.Done()
+			defer mx.handlers.stats.active.Add(-1)
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			rctx := mx.acquire() // Get a context from the pool.
+			rctx.Reset()
+			rctx.Copy(ctx.(*BusContext)) // Copy the context to the new context.
+			rctx.ctx = cctx              // Observe the cancellation of the batch.
 
-		wg.Wait()
-		close(errs) // Close the channel after all goroutines are done.
+			defer mx.release(rctx) // Ensure the context is put back into the pool.
 
-		// Collect errors from the channel.
-		var combinedError error
-		for err := range errs {
-			if combinedError == nil {
-				combinedError = err
-			} else {
-				combinedError = errors.Join(combinedError, err)
+			if err := fn(rctx, cmd); err != nil {
+				// Annotate errors with the failing command.
+				err = &CommandError{Type: reflect.TypeOf(cmd.Command()).Elem(), Index: i, Err: err}
+				if mx.failFast {
+					once.Do(func() {
+						first = err
+						cancel()
+					})
+					return
+				}
+				errs <- err
 			}
+		}(i, cmd)
+	}
+
+	wg.Wait()
+	close(errs) // Close the channel after all goroutines are done.
+
+	if first != nil {
+		return mx.aggregateErrors([]error{first})
+	}
+	if skipped {
+		// The commands were skipped as the parent context is done.
+		return ctx.Context().Err()
+	}
+
+	// Collect errors from the channel.
+	var collected []error
+	for err := range errs {
+		collected = append(collected, err)
+	}
+
+	return mx.aggregateErrors(collected)
+}
+
+// CommandError annotates an error with the command that produced it.
+type CommandError struct {
+	// Type is the type of the command.
+	Type reflect.Type
+	// Index is the position of the command in the batch.
+	Index int
+	// Err is the error returned for the command.
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%v #%d: %v", e.Type, e.Index, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// QueryReduce executes every handler registered for the query type concurrently,
+// each on its own copy of the query, and folds the results with the reduce function
+// in registration order. If any handler fails, the errors are joined and returned.
+func QueryReduce[T QueryAction, R any](ctx context.Context, query *T, initial R, reduce func(acc R, result *T) R) (R, error) {
+	bus, ok := FromContext(ctx)
+	if !ok {
+		return initial, ErrBusNotInContext
+	}
+
+	mux := bus.(*mux)
+	typ := typeFor[T]()
+	h, ok := mux.handlers.load().lookup(QUERY, typ)
+	if !ok {
+		mux.handlers.notifyUnhandled(typ, QUERY)
+		err := fmt.Errorf("%w for %v", ErrHandlerNotFound, typ)
+		mux.handlers.reportError(query, err)
+		return initial, err
+	}
+
+	all := h.all
+	results := make([]*T, len(all))
+	queries := make([]CommandHandler[Command], len(all))
+	for i, h := range all {
+		cp := *query
+		results[i] = &cp
+		queries[i] = &command[T]{
+			cmd:     &cp,
+			typ:     typ,
+			op:      QUERY,
+			handler: convertInterface[HandlerFunc[T]](h.handler),
+			mux:     h.mux,
 		}
+	}
 
-		return combinedError
-	})
+	if err := mux.queryAsync(ctx, queries, nil, 0); err != nil {
+		return initial, err
+	}
 
+	acc := initial
+	for _, result := range results {
+		acc = reduce(acc, result)
+	}
+	return acc, nil
 }
@@ -0,0 +1,81 @@
+// Package dewhttp provides HTTP adapters for the dew command bus.
+package dewhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/go-dew/dew"
+)
+
+// StreamQuery returns an http.Handler that executes a streaming query and writes
+// each item emitted by the handler as newline-delimited JSON.
+// The response is flushed after each item, so it is sent with chunked transfer encoding.
+// The handler's context is cancelled when the client disconnects,
+// and records dew.TransportHTTP as its transport.
+func StreamQuery[T dew.QueryAction, I any](bus dew.Bus, decode func(r *http.Request) (*T, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, err := decode(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := dew.WithTransport(dew.NewContext(r.Context(), bus), dew.TransportHTTP)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		flusher, _ := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+		written := false
+		// The handler may emit items concurrently, e.g. from QueryAsync.
+		var mu sync.Mutex
+
+		err = dew.QueryStream(ctx, query, func(item I) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !written {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.WriteHeader(http.StatusOK)
+				written = true
+			}
+			if err := enc.Encode(item); err != nil {
+				// The client is gone; stop the handler.
+				cancel()
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			if !written {
+				writeError(w, err)
+			}
+			return
+		}
+		if !written {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+// writeError writes the error response for the given error.
+// A dew.RetryAfterError is reported as 503 Service Unavailable with a Retry-After header.
+func writeError(w http.ResponseWriter, err error) {
+	var retryErr *dew.RetryAfterError
+	if errors.As(err, &retryErr) {
+		seconds := int(math.Ceil(retryErr.After.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
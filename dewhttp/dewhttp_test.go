@@ -0,0 +1,194 @@
+package dewhttp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewhttp"
+)
+
+type exportItems struct {
+	Count int
+}
+
+type item struct {
+	ID int `json:"id"`
+}
+
+func decodeExport(r *http.Request) (*exportItems, error) {
+	return &exportItems{Count: 3}, nil
+}
+
+func TestStreamQuery(t *testing.T) {
+	bus := dew.New()
+	bus.Register(dew.HandlerFunc[exportItems](
+		func(ctx context.Context, query *exportItems) error {
+			for i := 1; i <= query.Count; i++ {
+				if err := dew.Emit(ctx, item{ID: i}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	))
+
+	srv := httptest.NewServer(dewhttp.StreamQuery[exportItems, item](bus, decodeExport))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	if len(res.TransferEncoding) == 0 || res.TransferEncoding[0] != "chunked" {
+		t.Fatalf("expected chunked transfer encoding, got: %v", res.TransferEncoding)
+	}
+
+	var items []item
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var it item
+		if err := json.Unmarshal(scanner.Bytes(), &it); err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, it)
+	}
+	if len(items) != 3 {
+		t.Fatalf("unexpected items: %v", items)
+	}
+	for i, it := range items {
+		if it.ID != i+1 {
+			t.Fatalf("unexpected item: %v", it)
+		}
+	}
+}
+
+func TestStreamQuery_Concurrent(t *testing.T) {
+	bus := dew.New()
+	bus.Register(dew.HandlerFunc[exportItems](
+		func(ctx context.Context, query *exportItems) error {
+			var wg sync.WaitGroup
+			for i := 1; i <= 10; i++ {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+					_ = dew.Emit(ctx, item{ID: id})
+				}(i)
+			}
+			wg.Wait()
+			return nil
+		},
+	))
+
+	rec := httptest.NewRecorder()
+	dewhttp.StreamQuery[exportItems, item](bus, decodeExport).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	seen := make(map[int]bool)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var it item
+		if err := json.Unmarshal(scanner.Bytes(), &it); err != nil {
+			t.Fatal(err)
+		}
+		seen[it.ID] = true
+	}
+	if len(seen) != 10 {
+		t.Fatalf("unexpected items: %v", seen)
+	}
+}
+
+func TestStreamQuery_ClientDisconnect(t *testing.T) {
+	cancelled := make(chan struct{})
+
+	bus := dew.New()
+	bus.Register(dew.HandlerFunc[exportItems](
+		func(ctx context.Context, query *exportItems) error {
+			for i := 0; ; i++ {
+				select {
+				case <-ctx.Done():
+					close(cancelled)
+					return ctx.Err()
+				case <-time.After(10 * time.Millisecond):
+				}
+				_ = dew.Emit(ctx, item{ID: i})
+			}
+		},
+	))
+
+	srv := httptest.NewServer(dewhttp.StreamQuery[exportItems, item](bus, decodeExport))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(res.Body)
+	if !scanner.Scan() {
+		t.Fatal("expected an item")
+	}
+	res.Body.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler context was not cancelled after client disconnect")
+	}
+}
+
+func TestStreamQuery_RetryAfter(t *testing.T) {
+	errBusy := errors.New("busy")
+
+	bus := dew.New()
+	bus.Register(dew.HandlerFunc[exportItems](
+		func(ctx context.Context, query *exportItems) error {
+			return fmt.Errorf("export: %w", &dew.RetryAfterError{After: 1500 * time.Millisecond, Err: errBusy})
+		},
+	))
+
+	rec := httptest.NewRecorder()
+	dewhttp.StreamQuery[exportItems, item](bus, decodeExport).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if ra := rec.Header().Get("Retry-After"); ra != "2" {
+		t.Fatalf("unexpected Retry-After: %q", ra)
+	}
+}
+
+func TestStreamQuery_Transport(t *testing.T) {
+	var transport string
+	bus := dew.New()
+	bus.Register(dew.HandlerFunc[exportItems](
+		func(ctx context.Context, query *exportItems) error {
+			transport = dew.TransportFromContext(ctx)
+			return nil
+		},
+	))
+
+	rec := httptest.NewRecorder()
+	dewhttp.StreamQuery[exportItems, item](bus, decodeExport).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if transport != dew.TransportHTTP {
+		t.Fatalf("unexpected transport: %q", transport)
+	}
+}
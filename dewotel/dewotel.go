@@ -0,0 +1,43 @@
+// Package dewotel provides OpenTelemetry tracing for the dew command bus.
+package dewotel
+
+import (
+	"reflect"
+
+	"github.com/go-dew/dew"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/go-dew/dew/dewotel"
+
+// Middleware returns a command middleware that creates a span for each command.
+// The span is started from the span in the incoming context, so commands issued
+// re-entrantly by a handler become children of the handler's span.
+//
+//	bus.Use(dew.ALL, dewotel.Middleware(otel.GetTracerProvider()))
+func Middleware(tp trace.TracerProvider) func(next dew.Middleware) dew.Middleware {
+	tracer := tp.Tracer(instrumentationName)
+	return func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			name := "dew.command"
+			if cmd := ctx.Command(); cmd != nil {
+				name = reflect.TypeOf(cmd).Elem().String()
+			}
+			parent := ctx.Context()
+			spanCtx, span := tracer.Start(parent, name,
+				trace.WithAttributes(attribute.String("dew.command", name)),
+			)
+			defer span.End()
+			defer ctx.WithContext(parent)
+
+			err := next.Handle(ctx.WithContext(spanCtx))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package dewotel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewotel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type findUser struct {
+	ID     int
+	Result string
+}
+
+type findUserProfile struct {
+	ID     int
+	Result string
+}
+
+func TestMiddleware_Reentrant(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	errNotFound := errors.New("not found")
+
+	bus := dew.New()
+	bus.Use(dew.ALL, dewotel.Middleware(tp))
+	bus.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			if query.ID != 1 {
+				return errNotFound
+			}
+			query.Result = "john"
+			return nil
+		},
+	))
+	bus.Register(dew.HandlerFunc[findUserProfile](
+		func(ctx context.Context, query *findUserProfile) error {
+			user, err := dew.Query(ctx, &findUser{ID: query.ID})
+			if err != nil {
+				return err
+			}
+			query.Result = user.Result
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), bus)
+
+	if _, err := dew.Query(ctx, &findUserProfile{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected spans: %d", len(spans))
+	}
+	inner, outer := spans[0], spans[1]
+	if outer.Name() != "dewotel_test.findUserProfile" || inner.Name() != "dewotel_test.findUser" {
+		t.Fatalf("unexpected span names: %s, %s", outer.Name(), inner.Name())
+	}
+	if outer.Parent().IsValid() {
+		t.Fatalf("expected the outer span to be a root span")
+	}
+	if inner.Parent().SpanID() != outer.SpanContext().SpanID() {
+		t.Fatalf("expected the sub-query span to be a child of the handler span")
+	}
+	if inner.SpanContext().TraceID() != outer.SpanContext().TraceID() {
+		t.Fatalf("expected the spans to share the trace")
+	}
+
+	// errors are recorded on the span
+	if _, err := dew.Query(ctx, &findUser{ID: 2}); !errors.Is(err, errNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spans = recorder.Ended()
+	if status := spans[len(spans)-1].Status(); status.Code != codes.Error {
+		t.Fatalf("unexpected status: %v", status)
+	}
+}
+
+func TestMiddleware_RestoresContext(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+
+	var after trace.Span
+	bus := dew.New()
+	bus.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			err := next.Handle(ctx)
+			after = trace.SpanFromContext(ctx.Context())
+			return err
+		})
+	}, dewotel.Middleware(tp))
+	bus.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), bus)
+
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// the middlewares running after the span ended no longer see it
+	if after.SpanContext().IsValid() {
+		t.Fatalf("expected the parent context to be restored")
+	}
+}
@@ -0,0 +1,73 @@
+// Package dewprometheus provides Prometheus metrics for the dew command bus.
+package dewprometheus
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsMiddleware returns a command middleware recording the duration of each command
+// in the dew_command_duration_seconds histogram, and its errors in the dew_command_errors_total
+// counter. Both are labelled by the command type name, without its package path to keep the
+// cardinality bounded, and by the operation type, "action" or "query".
+// The metrics are registered once: calling it again with the same registerer, e.g. for
+// several groups, reuses the registered metrics.
+//
+//	bus.Use(dew.ALL, dewprometheus.MetricsMiddleware(prometheus.DefaultRegisterer))
+func MetricsMiddleware(reg prometheus.Registerer) func(next dew.Middleware) dew.Middleware {
+	duration := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dew_command_duration_seconds",
+		Help:    "Duration of the command executions in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"command", "op"}))
+	errs := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dew_command_errors_total",
+		Help: "Number of the command executions that returned an error.",
+	}, []string{"command", "op"}))
+
+	return func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			command, op := labels(ctx)
+			start := time.Now()
+			err := next.Handle(ctx)
+			duration.WithLabelValues(command, op).Observe(time.Since(start).Seconds())
+			if err != nil {
+				errs.WithLabelValues(command, op).Inc()
+			}
+			return err
+		})
+	}
+}
+
+// register registers the collector, or returns the already registered one.
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// labels returns the command type name and operation type labels of the command being executed.
+func labels(ctx dew.Context) (string, string) {
+	cmd := ctx.Command()
+	if cmd == nil {
+		return "", ""
+	}
+	op := strings.ToLower(ctx.Op().String())
+	t := reflect.TypeOf(cmd)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name(), op
+}
@@ -0,0 +1,101 @@
+package dewprometheus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewprometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type findUser struct {
+	ID     int
+	Result string
+}
+
+type createUser struct {
+	Name string
+}
+
+func (c createUser) Validate(_ context.Context) error { return nil }
+
+func TestMetricsMiddleware(t *testing.T) {
+	errNotFound := errors.New("not found")
+	reg := prometheus.NewRegistry()
+
+	bus := dew.New()
+	bus.Use(dew.ALL, dewprometheus.MetricsMiddleware(reg))
+	bus.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			if query.ID != 1 {
+				return errNotFound
+			}
+			query.Result = "john"
+			return nil
+		},
+	))
+	// registering the metrics again, e.g. for a group, reuses the existing ones
+	bus.Group(func(bus dew.Bus) {
+		bus.Use(dew.ACTION, dewprometheus.MetricsMiddleware(reg))
+		bus.Register(dew.HandlerFunc[createUser](
+			func(ctx context.Context, action *createUser) error {
+				return nil
+			},
+		))
+	})
+
+	ctx := dew.NewContext(context.Background(), bus)
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.Query(ctx, &findUser{ID: 2}); !errors.Is(err, errNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "john"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	metrics := make(map[string][]*dto.Metric)
+	for _, f := range families {
+		metrics[f.GetName()] = f.GetMetric()
+	}
+
+	durations := samples(metrics["dew_command_duration_seconds"], func(m *dto.Metric) float64 {
+		return float64(m.GetHistogram().GetSampleCount())
+	})
+	// the action goes through the middleware of the bus and of the group
+	if durations["findUser/query"] != 2 || durations["createUser/action"] != 2 {
+		t.Fatalf("unexpected durations: %v", durations)
+	}
+	errs := samples(metrics["dew_command_errors_total"], func(m *dto.Metric) float64 {
+		return m.GetCounter().GetValue()
+	})
+	if len(errs) != 1 || errs["findUser/query"] != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+// samples returns the values of the metrics by "command/op" labels.
+func samples(metrics []*dto.Metric, value func(m *dto.Metric) float64) map[string]float64 {
+	values := make(map[string]float64)
+	for _, m := range metrics {
+		var command, op string
+		for _, l := range m.GetLabel() {
+			switch l.GetName() {
+			case "command":
+				command = l.GetValue()
+			case "op":
+				op = l.GetValue()
+			}
+		}
+		values[command+"/"+op] = value(m)
+	}
+	return values
+}
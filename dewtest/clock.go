@@ -0,0 +1,127 @@
+package dewtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-dew/dew"
+)
+
+// FakeClock is a dew.Clock whose time only moves when advanced, so the time-based
+// features of the bus can be tested without real sleeps:
+//
+//	clock := dewtest.NewFakeClock(time.Now())
+//	bus := dew.New(dew.WithClock(clock))
+//	...
+//	clock.Advance(time.Minute)
+//
+// The zero value is a clock set to the zero time.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	changed chan struct{}
+}
+
+type fakeTimer struct {
+	clock   *FakeClock
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time of the clock once it has been advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.AfterFunc(d, func() { ch <- c.Now() })
+	return ch
+}
+
+// AfterFunc calls f once the clock has been advanced by d.
+// Unlike time.AfterFunc, f is called by Advance, in the goroutine advancing the clock.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) dew.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	c.notify()
+	return t
+}
+
+// Advance moves the clock forward and runs the timers that are due, in the order they were created.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due, rest []*fakeTimer
+	for _, t := range c.timers {
+		if t.stopped {
+			continue
+		}
+		if !t.at.After(c.now) {
+			t.stopped = true
+			due = append(due, t)
+		} else {
+			rest = append(rest, t)
+		}
+	}
+	c.timers = rest
+	c.mu.Unlock()
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// BlockUntil blocks until at least n timers are pending, e.g. until the code under test,
+// running in another goroutine, waits for the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		pending := 0
+		for _, t := range c.timers {
+			if !t.stopped {
+				pending++
+			}
+		}
+		if pending >= n {
+			c.mu.Unlock()
+			return
+		}
+		if c.changed == nil {
+			c.changed = make(chan struct{})
+		}
+		changed := c.changed
+		c.mu.Unlock()
+		<-changed
+	}
+}
+
+// notify wakes up the goroutines blocked in BlockUntil. c.mu must be held.
+func (c *FakeClock) notify() {
+	if c.changed != nil {
+		close(c.changed)
+		c.changed = nil
+	}
+}
+
+// Stop prevents the timer from firing.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	if t.stopped {
+		return false
+	}
+	t.stopped = true
+	return true
+}
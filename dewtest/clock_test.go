@@ -0,0 +1,63 @@
+package dewtest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew/dewtest"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := dewtest.NewFakeClock(start)
+
+	after := clock.After(time.Minute)
+	var fired []string
+	clock.AfterFunc(2*time.Minute, func() { fired = append(fired, "second") })
+	stopped := clock.AfterFunc(time.Minute, func() { fired = append(fired, "stopped") })
+	if !stopped.Stop() || stopped.Stop() {
+		t.Fatal("expected the timer to be stopped once")
+	}
+
+	clock.Advance(59 * time.Second)
+	select {
+	case <-after:
+		t.Fatal("fired before the duration elapsed")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case now := <-after:
+		if !now.Equal(start.Add(time.Minute)) {
+			t.Fatalf("unexpected time: %v", now)
+		}
+	default:
+		t.Fatal("expected the channel to receive the time")
+	}
+
+	clock.Advance(time.Minute)
+	if len(fired) != 1 || fired[0] != "second" {
+		t.Fatalf("unexpected timers: %v", fired)
+	}
+	if now := clock.Now(); !now.Equal(start.Add(2 * time.Minute)) {
+		t.Fatalf("unexpected time: %v", now)
+	}
+}
+
+func TestFakeClock_BlockUntil(t *testing.T) {
+	clock := new(dewtest.FakeClock)
+	done := make(chan struct{})
+	go func() {
+		<-clock.After(time.Second)
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the timer did not fire")
+	}
+}
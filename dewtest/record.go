@@ -0,0 +1,96 @@
+// Package dewtest provides utilities for testing code built on the dew command bus.
+package dewtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-dew/dew"
+)
+
+// RecordedCommand is a serialized command of a Recording.
+type RecordedCommand struct {
+	// Type is the type of the command.
+	Type reflect.Type
+	// Payload is the JSON encoding of the command when it was dispatched.
+	Payload json.RawMessage
+}
+
+// Recording is a log of the actions dispatched to a bus.
+type Recording struct {
+	mu       sync.Mutex
+	commands []RecordedCommand
+	err      error
+}
+
+type recordingKey struct{}
+
+// Record starts recording the actions dispatched to the bus and its groups created afterwards.
+// Like Use, it must be called before the first action is dispatched. Actions are serialized
+// to JSON before their handler runs. Actions dispatched by a handler are not recorded,
+// as replaying their parent dispatches them again.
+func Record(bus dew.Bus) *Recording {
+	r := &Recording{}
+	bus.Use(dew.ACTION, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			if ctx.Context().Value(recordingKey{}) == r {
+				return next.Handle(ctx)
+			}
+			r.add(ctx.Command())
+			parent := ctx.Context()
+			defer ctx.WithContext(parent)
+			return next.Handle(ctx.WithValue(recordingKey{}, r))
+		})
+	})
+	return r
+}
+
+func (r *Recording) add(cmd dew.Command) {
+	payload, err := json.Marshal(cmd)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("dewtest: record %T: %w", cmd, err)
+		}
+		return
+	}
+	r.commands = append(r.commands, RecordedCommand{Type: reflect.TypeOf(cmd).Elem(), Payload: payload})
+}
+
+// Commands returns the recorded commands in dispatch order.
+func (r *Recording) Commands() []RecordedCommand {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedCommand(nil), r.commands...)
+}
+
+// Err returns the first error that occurred while serializing a command.
+func (r *Recording) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Replay dispatches the recorded actions to the bus in order.
+// It stops at the first action that fails.
+func (r *Recording) Replay(bus dew.Bus) error {
+	ctx := dew.NewContext(context.Background(), bus)
+	for i, c := range r.Commands() {
+		v := reflect.New(c.Type)
+		if err := json.Unmarshal(c.Payload, v.Interface()); err != nil {
+			return fmt.Errorf("dewtest: replay #%d %v: %w", i, c.Type, err)
+		}
+		action, ok := v.Interface().(dew.Action)
+		if !ok {
+			return fmt.Errorf("dewtest: replay #%d: %v is not an action", i, c.Type)
+		}
+		if err := dew.DispatchAny(ctx, action); err != nil {
+			return fmt.Errorf("dewtest: replay #%d %v: %w", i, c.Type, err)
+		}
+	}
+	return nil
+}
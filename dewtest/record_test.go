@@ -0,0 +1,109 @@
+package dewtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+type deposit struct {
+	Account string
+	Amount  int
+}
+
+func (deposit) Validate(context.Context) error { return nil }
+
+type withdraw struct {
+	Account string
+	Amount  int
+}
+
+func (c withdraw) Validate(context.Context) error {
+	if c.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	return nil
+}
+
+type transfer struct {
+	From, To string
+	Amount   int
+}
+
+func (transfer) Validate(context.Context) error { return nil }
+
+func newBank(balances map[string]int) dew.Bus {
+	bus := dew.New()
+	bus.Register(dew.HandlerFunc[deposit](func(ctx context.Context, cmd *deposit) error {
+		balances[cmd.Account] += cmd.Amount
+		return nil
+	}))
+	bus.Register(dew.HandlerFunc[withdraw](func(ctx context.Context, cmd *withdraw) error {
+		balances[cmd.Account] -= cmd.Amount
+		return nil
+	}))
+	bus.Register(dew.HandlerFunc[transfer](func(ctx context.Context, cmd *transfer) error {
+		if _, err := dew.Dispatch(ctx, &withdraw{Account: cmd.From, Amount: cmd.Amount}); err != nil {
+			return err
+		}
+		_, err := dew.Dispatch(ctx, &deposit{Account: cmd.To, Amount: cmd.Amount})
+		return err
+	}))
+	return bus
+}
+
+func TestRecord(t *testing.T) {
+	balances := map[string]int{}
+	bus := newBank(balances)
+	recording := dewtest.Record(bus)
+	ctx := dew.NewContext(context.Background(), bus)
+
+	if err := dew.DispatchMulti(ctx,
+		dew.NewAction(&deposit{Account: "alice", Amount: 100}),
+		dew.NewAction(&transfer{From: "alice", To: "bob", Amount: 30}),
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.Dispatch(ctx, &withdraw{Account: "bob", Amount: 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := recording.Err(); err != nil {
+		t.Fatalf("unexpected recording error: %v", err)
+	}
+
+	// Actions dispatched by the transfer handler are not recorded.
+	if n := len(recording.Commands()); n != 3 {
+		t.Fatalf("expected 3 recorded commands, got %d", n)
+	}
+
+	replayed := map[string]int{}
+	if err := recording.Replay(newBank(replayed)); err != nil {
+		t.Fatalf("unexpected replay error: %v", err)
+	}
+	for account, balance := range balances {
+		if replayed[account] != balance {
+			t.Fatalf("unexpected balance for %s: %d, want %d", account, replayed[account], balance)
+		}
+	}
+	if replayed["alice"] != 70 || replayed["bob"] != 20 {
+		t.Fatalf("unexpected balances: %v", replayed)
+	}
+}
+
+func TestRecord_ReplayError(t *testing.T) {
+	bus := newBank(map[string]int{})
+	recording := dewtest.Record(bus)
+	ctx := dew.NewContext(context.Background(), bus)
+
+	if _, err := dew.Dispatch(ctx, &deposit{Account: "alice", Amount: 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The fresh bus has no handler for the recorded action.
+	if err := recording.Replay(dew.New()); err == nil {
+		t.Fatal("expected a replay error, but got nil")
+	}
+}
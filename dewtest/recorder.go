@@ -0,0 +1,122 @@
+package dewtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+// Recorder is a bus recording the commands executed by the code under test,
+// without requiring their handlers. Commands without a registered handler succeed
+// without doing anything, unless a query is stubbed with StubQuery.
+type Recorder struct {
+	bus dew.Bus
+
+	mu         sync.Mutex
+	dispatched []dew.Command
+	queried    []dew.Command
+	stubs      map[reflect.Type]reflect.Value
+}
+
+// NewRecorder returns a Recorder. Handlers can still be registered to its Bus,
+// e.g. for the commands whose behavior matters to the test.
+func NewRecorder() *Recorder {
+	r := &Recorder{stubs: make(map[reflect.Type]reflect.Value)}
+	r.bus = dew.New(dew.WithFallbackHandler(func(ctx context.Context, cmd dew.Command) error {
+		return nil
+	}))
+	r.bus.Use(dew.ALL, r.record)
+	return r
+}
+
+// Bus returns the bus of the recorder.
+func (r *Recorder) Bus() dew.Bus {
+	return r.bus
+}
+
+// Context returns a new context carrying the bus of the recorder, to pass to the code under test.
+func (r *Recorder) Context(ctx context.Context) context.Context {
+	return dew.NewContext(ctx, r.bus)
+}
+
+// record records the command as it was when dispatched, and executes the stub of queries.
+func (r *Recorder) record(next dew.Middleware) dew.Middleware {
+	return dew.MiddlewareFunc(func(ctx dew.Context) error {
+		cmd := ctx.Command()
+		v := reflect.ValueOf(cmd).Elem()
+		cp := reflect.New(v.Type())
+		cp.Elem().Set(v)
+
+		r.mu.Lock()
+		if ctx.Op() == dew.ACTION {
+			r.dispatched = append(r.dispatched, cp.Interface())
+		} else {
+			r.queried = append(r.queried, cp.Interface())
+		}
+		stub, ok := r.stubs[v.Type()]
+		r.mu.Unlock()
+
+		if ok && ctx.Op() == dew.QUERY {
+			stub.Call([]reflect.Value{reflect.ValueOf(cmd)})
+			return nil
+		}
+		return next.Handle(ctx)
+	})
+}
+
+// StubQuery sets the function filling the result of the queries of the type of the sample,
+// e.g. &FindUser{}. The function has the signature func(*T), where T is the query type:
+//
+//	rec.StubQuery(&FindUser{}, func(q *FindUser) { q.Result = &User{ID: q.ID} })
+//
+// The stub takes precedence over a registered handler. It panics if fn does not match the sample.
+func (r *Recorder) StubQuery(sample dew.Command, fn any) {
+	t := reflect.TypeOf(sample)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	f := reflect.ValueOf(fn)
+	if t == nil || f.Kind() != reflect.Func || f.Type().NumIn() != 1 || f.Type().In(0) != reflect.PtrTo(t) {
+		panic(fmt.Sprintf("dewtest: StubQuery requires a func(*%v), got %T", t, fn))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stubs[t] = f
+}
+
+// Dispatched returns copies of the dispatched actions, as they were when dispatched, in order.
+func (r *Recorder) Dispatched() []dew.Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]dew.Command(nil), r.dispatched...)
+}
+
+// Queried returns copies of the executed queries, as they were when executed, in order.
+func (r *Recorder) Queried() []dew.Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]dew.Command(nil), r.queried...)
+}
+
+// AssertDispatched reports a test error unless an action equal to expected, e.g. &CreateUser{Name: "john"},
+// was dispatched.
+func (r *Recorder) AssertDispatched(t testing.TB, expected dew.Command) {
+	t.Helper()
+	dispatched := r.Dispatched()
+	for _, cmd := range dispatched {
+		if reflect.DeepEqual(cmd, expected) {
+			return
+		}
+	}
+	lines := make([]string, len(dispatched))
+	for i, cmd := range dispatched {
+		lines[i] = fmt.Sprintf("\t%T%+v", cmd, reflect.ValueOf(cmd).Elem().Interface())
+	}
+	t.Errorf("dewtest: %T%+v was not dispatched; dispatched:\n%s",
+		expected, reflect.ValueOf(expected).Elem().Interface(), strings.Join(lines, "\n"))
+}
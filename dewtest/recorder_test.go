@@ -0,0 +1,87 @@
+package dewtest_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+type getBalance struct {
+	Account string
+	Result  int
+}
+
+// payRent is the business logic under test.
+func payRent(ctx context.Context, account string) error {
+	balance, err := dew.Query(ctx, &getBalance{Account: account})
+	if err != nil {
+		return err
+	}
+	if balance.Result < 100 {
+		return fmt.Errorf("insufficient balance: %d", balance.Result)
+	}
+	return dew.DispatchMulti(ctx,
+		dew.NewAction(&withdraw{Account: account, Amount: 100}),
+		dew.NewAction(&deposit{Account: "landlord", Amount: 100}),
+	)
+}
+
+// fakeT records the errors reported to it.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	rec := dewtest.NewRecorder()
+	rec.StubQuery(&getBalance{}, func(q *getBalance) {
+		q.Result = 150
+	})
+	ctx := rec.Context(context.Background())
+
+	if err := payRent(ctx, "alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec.AssertDispatched(t, &withdraw{Account: "alice", Amount: 100})
+	rec.AssertDispatched(t, &deposit{Account: "landlord", Amount: 100})
+	if dispatched := rec.Dispatched(); len(dispatched) != 2 {
+		t.Fatalf("unexpected actions: %v", dispatched)
+	}
+	queried := rec.Queried()
+	if len(queried) != 1 || *queried[0].(*getBalance) != (getBalance{Account: "alice"}) {
+		t.Fatalf("unexpected queries: %v", queried)
+	}
+
+	ft := &fakeT{}
+	rec.AssertDispatched(ft, &deposit{Account: "bob", Amount: 100})
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "*dewtest_test.deposit{Account:bob Amount:100} was not dispatched") {
+		t.Fatalf("unexpected errors: %v", ft.errors)
+	}
+}
+
+func TestRecorder_RegisteredHandler(t *testing.T) {
+	rec := dewtest.NewRecorder()
+	rec.Bus().Register(dew.HandlerFunc[getBalance](func(ctx context.Context, q *getBalance) error {
+		q.Result = 50
+		return nil
+	}))
+	ctx := rec.Context(context.Background())
+
+	if err := payRent(ctx, "alice"); err == nil || !strings.Contains(err.Error(), "insufficient balance: 50") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dispatched := rec.Dispatched(); len(dispatched) != 0 {
+		t.Fatalf("unexpected actions: %v", dispatched)
+	}
+}
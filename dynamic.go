@@ -0,0 +1,93 @@
+package dew
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// DispatchAny executes the action whose type is only known at runtime,
+// e.g. an action decoded from a log. The action must be a pointer to a command struct.
+func DispatchAny(ctx context.Context, action Action) error {
+	typ := reflect.TypeOf(action)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return fmt.Errorf("dew: DispatchAny requires a pointer to an action, got %T", action)
+	}
+	return DispatchMulti(ctx, &anyCommand{cmd: action, typ: typ.Elem(), op: ACTION})
+}
+
+// anyCommand is a command whose type is only known at runtime.
+// Its handler is called through reflection.
+type anyCommand struct {
+	mux     *mux
+	cmd     Action
+	handler reflect.Value
+	typ     reflect.Type
+	op      OpType
+}
+
+func (c *anyCommand) Handle(ctx Context) error {
+	if reflect.ValueOf(c.cmd).IsNil() {
+		return fmt.Errorf("%w: %v", ErrNilCommand, c.typ)
+	}
+	if l, ok := c.mux.handlers.limits.Load(c.typ); ok {
+		l := l.(*limiter)
+		if err := l.acquire(ctx.Context()); err != nil {
+			return fmt.Errorf("%v: %w", c.typ, err)
+		}
+		defer l.release()
+	}
+	handler := c.handler
+	if bctx, ok := ctx.(*BusContext); ok && bctx.overrides != nil {
+		if h, ok := bctx.overrides[c.typ]; ok {
+			handler = reflect.ValueOf(h)
+		}
+	}
+	err, _ := handler.Call([]reflect.Value{reflect.ValueOf(ctx.Context()), reflect.ValueOf(c.cmd)})[0].Interface().(error)
+	return err
+}
+
+func (c *anyCommand) Command() Command {
+	return c.cmd
+}
+
+func (c *anyCommand) Mux() *mux {
+	return c.mux
+}
+
+func (c *anyCommand) Resolve(bus Bus) error {
+	return c.resolveIn(context.Background(), bus)
+}
+
+// resolveIn resolves the handler of the command on the bus, like command.resolveIn.
+func (c *anyCommand) resolveIn(ctx context.Context, bus Bus) error {
+	if reflect.ValueOf(c.cmd).IsNil() {
+		return fmt.Errorf("%w: %v", ErrNilCommand, c.typ)
+	}
+
+	r := bus.(*mux).handlers
+	set := r.load()
+	h, ok := set.lookup(c.op, c.typ)
+	if !ok {
+		if h, found := overrideFor(ctx, c.typ); found {
+			c.handler = reflect.ValueOf(h)
+			c.mux = bus.(*mux).setupOverride()
+			return nil
+		}
+	}
+	if !ok && r.fallback != nil {
+		c.handler = reflect.ValueOf(r.fallback)
+		c.mux = bus.(*mux)
+		return nil
+	}
+	if !ok {
+		r.notifyUnhandled(c.typ, c.op)
+		return fmt.Errorf("%w for %v", ErrHandlerNotFound, c.typ)
+	}
+	start := time.Now()
+	c.handler = reflect.ValueOf(h.handler)
+	c.mux = h.mux
+	h.mux.firstUse(set, c.op, c.typ, start)
+	return nil
+}
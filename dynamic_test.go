@@ -0,0 +1,36 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestDispatchAny(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	var action dew.Action = &createUser{Name: "john"}
+	if err := dew.DispatchAny(ctx, action); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action.(*createUser).Result != "user created" {
+		t.Fatalf("unexpected result: %s", action.(*createUser).Result)
+	}
+
+	if err := dew.DispatchAny(ctx, &createUser{}); !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := dew.DispatchAny(ctx, createUser{Name: "john"}); err == nil {
+		t.Fatal("expected an error for a non-pointer action, but got nil")
+	}
+	if err := dew.DispatchAny(ctx, (*createUser)(nil)); !errors.Is(err, dew.ErrNilCommand) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := dew.DispatchAny(ctx, &createPost{Title: "hello"}); err == nil {
+		t.Fatal("expected a handler not found error, but got nil")
+	}
+}
@@ -0,0 +1,9 @@
+package dew
+
+// WithIdempotencyWaitHook sets a function called when a command starts waiting
+// for an execution in flight, to synchronize tests with the waiters.
+func WithIdempotencyWaitHook(fn func()) IdempotencyOption {
+	return func(s *idempotencyStore) {
+		s.onWait = fn
+	}
+}
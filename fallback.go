@@ -0,0 +1,14 @@
+package dew
+
+import "context"
+
+// WithFallbackHandler makes the bus execute the commands without a registered handler with fn,
+// instead of returning ErrHandlerNotFound, e.g. to forward them to another service or to record
+// them in tests. The middlewares are executed as for registered handlers.
+// It applies to the bus and all its groups, but not to Publish, QueryReduce, and QueryResult.
+func WithFallbackHandler(fn func(ctx context.Context, cmd Command) error) Option {
+	return func(mx *mux) {
+		mx.handlers.fallback = fn
+		mx.setupHandler()
+	}
+}
@@ -0,0 +1,49 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestWithFallbackHandler(t *testing.T) {
+	var forwarded []string
+	forward := func(ctx context.Context, cmd dew.Command) error {
+		forwarded = append(forwarded, fmt.Sprintf("%T", cmd))
+		if q, ok := cmd.(*findPost); ok {
+			q.Result = "remote"
+		}
+		return nil
+	}
+
+	mux := dew.New(dew.WithFallbackHandler(forward))
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunDispatch(t, ctx, dew.NewAction(&createPost{Title: "hello"}))
+	if result := testRunQuery(t, ctx, &findPost{ID: 1}); result.Result != "remote" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if err := dew.DispatchAny(ctx, &createPost{Title: "hello"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// registered handlers are executed
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if got := strings.Join(forwarded, ","); got != "*dew_test.createPost,*dew_test.findPost,*dew_test.createPost" {
+		t.Fatalf("unexpected forwarded commands: %s", got)
+	}
+
+	// the middlewares are executed
+	mux = dew.New(dew.WithFallbackHandler(forward))
+	mux.Use(dew.ALL, denyAll)
+	ctx = dew.NewContext(context.Background(), mux)
+	if _, err := dew.Dispatch(ctx, &createPost{Title: "hello"}); !errors.Is(err, errDenied) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package dew
+
+import "context"
+
+type flagsKey struct{}
+
+// WithFlags returns a new context with the feature flags set, e.g. from the headers of a request.
+// The flags are merged with the flags already set on the context, overriding them.
+func WithFlags(ctx context.Context, flags map[string]bool) context.Context {
+	merged := make(map[string]bool)
+	if parent, ok := ctx.Value(flagsKey{}).(map[string]bool); ok {
+		for name, on := range parent {
+			merged[name] = on
+		}
+	}
+	for name, on := range flags {
+		merged[name] = on
+	}
+	return context.WithValue(ctx, flagsKey{}, merged)
+}
+
+// FlagEnabled reports whether the named feature flag is on in the context.
+// Flags not set with WithFlags are off.
+func FlagEnabled(ctx context.Context, name string) bool {
+	flags, _ := ctx.Value(flagsKey{}).(map[string]bool)
+	return flags[name]
+}
+
+// Toggle returns a middleware running mw only when the named feature flag is on
+// in the context of the execution. Otherwise, mw is bypassed.
+//
+//	bus.Use(dew.QUERY, dew.Toggle("cache", dew.CacheMiddleware(time.Minute, nil)))
+func Toggle(name string, mw func(next Middleware) Middleware) func(next Middleware) Middleware {
+	return func(next Middleware) Middleware {
+		wrapped := mw(next)
+		return MiddlewareFunc(func(ctx Context) error {
+			if FlagEnabled(ctx.Context(), name) {
+				return wrapped.Handle(ctx)
+			}
+			return next.Handle(ctx)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package dew_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+)
+
+func TestToggle(t *testing.T) {
+	calls := 0
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.Toggle("cache", dew.CacheMiddleware(time.Minute, nil)))
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			calls++
+			query.Result = "john"
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+	cached := dew.WithFlags(ctx, map[string]bool{"cache": true})
+
+	testRunQuery(t, cached, &findUser{ID: 1})
+	testRunQuery(t, cached, &findUser{ID: 1})
+	if calls != 1 {
+		t.Fatalf("unexpected number of calls: %d", calls)
+	}
+
+	// the cache is bypassed for debug requests
+	debug := dew.WithFlags(cached, map[string]bool{"cache": false})
+	if result := testRunQuery(t, debug, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if calls != 2 {
+		t.Fatalf("unexpected number of calls: %d", calls)
+	}
+
+	// flags are off unless set
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	if calls != 3 {
+		t.Fatalf("unexpected number of calls: %d", calls)
+	}
+	if !dew.FlagEnabled(cached, "cache") || dew.FlagEnabled(debug, "cache") || dew.FlagEnabled(ctx, "cache") {
+		t.Fatal("unexpected flags")
+	}
+}
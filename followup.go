@@ -0,0 +1,68 @@
+package dew
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// FollowUpFunc is a handler returning a command to dispatch after it, e.g. the next step
+// of a simple workflow. Register it like a HandlerFunc:
+//
+//	bus.Register(dew.FollowUpFunc[PlaceOrder](func(ctx context.Context, action *PlaceOrder) (dew.Command, error) {
+//		return &ReserveStock{OrderID: action.ID}, nil
+//	}))
+//
+// The returned action is dispatched with the context of the handler, so it shares its values
+// and request with the command that returned it. A nil command ends the chain.
+// Handler methods of actions of the form func(ctx context.Context, command *T) (dew.Command, error)
+// are registered the same way.
+type FollowUpFunc[T any] func(ctx context.Context, command *T) (Command, error)
+
+// Handle calls the function f(ctx, command).
+func (f FollowUpFunc[T]) Handle(ctx context.Context, command *T) (Command, error) {
+	return f(ctx, command)
+}
+
+var commandIfaceType = reflect.TypeOf((*Command)(nil)).Elem()
+
+// isFollowUpMethod checks if the method is a handler method of an action returning
+// a follow-up command, as a dew.Command or a dew.Action.
+//
+//	func (oh *OrderHandler) Place(ctx context.Context, action *action.PlaceOrder) (dew.Command, error)
+func isFollowUpMethod(m reflect.Method) bool {
+	return m.Type.NumIn() == 3 && isContextType(m.Type.In(1)) && m.Type.In(2).Kind() == reflect.Ptr &&
+		opTypeOf(m.Type.In(2).Elem()) == ACTION &&
+		m.Type.NumOut() == 2 && (m.Type.Out(0) == commandIfaceType || m.Type.Out(0) == actionType) &&
+		isErrorType(m.Type.Out(1))
+}
+
+// followUpHandler adapts the follow-up method to a handler func(ctx, *T) error
+// dispatching the returned command.
+func followUpHandler(fn reflect.Value, cmdType reflect.Type) any {
+	fnType := reflect.FuncOf([]reflect.Type{ctxType, reflect.PtrTo(cmdType)}, []reflect.Type{errType}, false)
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		out := fn.Call(args)
+		next, _ := out[0].Interface().(Command)
+		err, _ := out[1].Interface().(error)
+		if err == nil {
+			err = dispatchFollowUp(args[0].Interface().(context.Context), next)
+		}
+		return []reflect.Value{reflect.ValueOf(&err).Elem()}
+	}).Interface()
+}
+
+// dispatchFollowUp dispatches the follow-up command, if any, with the handler context.
+func dispatchFollowUp(ctx context.Context, next Command) error {
+	if next == nil {
+		return nil
+	}
+	action, ok := next.(Action)
+	if !ok {
+		return fmt.Errorf("follow-up %T is not an action", next)
+	}
+	if err := DispatchAny(ctx, action); err != nil {
+		return fmt.Errorf("follow-up %T: %w", next, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type placeOrder struct {
+	ID int
+}
+
+func (c placeOrder) Validate(_ context.Context) error { return nil }
+
+type reserveStock struct {
+	OrderID int
+}
+
+func (c reserveStock) Validate(_ context.Context) error { return nil }
+
+type shipOrder struct {
+	OrderID int
+}
+
+func (c shipOrder) Validate(_ context.Context) error { return nil }
+
+type orderHandler struct {
+	shipped []int
+	trace   string
+}
+
+func (h *orderHandler) Reserve(ctx context.Context, action *reserveStock) (dew.Command, error) {
+	if action.OrderID == 0 {
+		return nil, errors.New("missing order")
+	}
+	return &shipOrder{OrderID: action.OrderID}, nil
+}
+
+func (h *orderHandler) Ship(ctx context.Context, action *shipOrder) error {
+	h.shipped = append(h.shipped, action.OrderID)
+	h.trace, _ = ctx.Value(ctxKey{"trace"}).(string)
+	return nil
+}
+
+func TestFollowUpFunc(t *testing.T) {
+	h := &orderHandler{}
+
+	mux := dew.New()
+	mux.Register(h)
+	mux.Register(dew.FollowUpFunc[placeOrder](
+		func(ctx context.Context, action *placeOrder) (dew.Command, error) {
+			switch {
+			case action.ID < 0:
+				return nil, nil
+			case action.ID == 99:
+				return &findUser{ID: 1}, nil
+			}
+			return &reserveStock{OrderID: action.ID}, nil
+		},
+	))
+	ctx := dew.NewContext(context.WithValue(context.Background(), ctxKey{"trace"}, "abc"), mux)
+
+	t.Run("Chain", func(t *testing.T) {
+		ctx := dew.WithBreadcrumbs(ctx, 0)
+		testRunDispatch(t, ctx, dew.NewAction(&placeOrder{ID: 1}))
+		if len(h.shipped) != 1 || h.shipped[0] != 1 {
+			t.Fatalf("unexpected shipped orders: %v", h.shipped)
+		}
+		// the follow-ups run with the context of the first command
+		if h.trace != "abc" {
+			t.Fatalf("unexpected trace: %q", h.trace)
+		}
+		if got := strings.Join(dew.Breadcrumbs(ctx), ","); got != "placeOrder,reserveStock,shipOrder" {
+			t.Fatalf("unexpected breadcrumbs: %s", got)
+		}
+	})
+
+	t.Run("NoFollowUp", func(t *testing.T) {
+		h.shipped = nil
+		testRunDispatch(t, ctx, dew.NewAction(&placeOrder{ID: -1}))
+		if len(h.shipped) != 0 {
+			t.Fatalf("unexpected shipped orders: %v", h.shipped)
+		}
+	})
+
+	t.Run("FollowUpError", func(t *testing.T) {
+		_, err := dew.Dispatch(ctx, &placeOrder{ID: 0})
+		if err == nil || !strings.Contains(err.Error(), "missing order") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("NotAnAction", func(t *testing.T) {
+		_, err := dew.Dispatch(ctx, &placeOrder{ID: 99})
+		if err == nil || !strings.Contains(err.Error(), "is not an action") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
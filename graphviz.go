@@ -0,0 +1,58 @@
+package dew
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ExportGraphviz returns a DOT graph of the registered command types, their handlers,
+// and the command middlewares each command passes through.
+func (mx *mux) ExportGraphviz() string {
+	type route struct {
+		cmd     reflect.Type
+		handler string
+		mws     []string
+	}
+
+	var routes []route
+	mx.handlers.load().rangeAll(func(key handlerKey, h *handler) bool {
+		routes = append(routes, route{cmd: key.t, handler: h.name, mws: h.mux.middlewareNames(key.op, key.t)})
+		return true
+	})
+	sort.Slice(routes, func(i, j int) bool { return routes[i].cmd.String() < routes[j].cmd.String() })
+
+	var b strings.Builder
+	b.WriteString("digraph dew {\n")
+	b.WriteString("\trankdir=LR;\n")
+	for _, r := range routes {
+		fmt.Fprintf(&b, "\t%q [shape=box];\n", r.cmd.String())
+		fmt.Fprintf(&b, "\t%q [shape=ellipse];\n", r.handler)
+		fmt.Fprintf(&b, "\t%q -> %q", r.cmd.String(), r.handler)
+		if len(r.mws) > 0 {
+			fmt.Fprintf(&b, " [label=%q]", strings.Join(r.mws, "\n"))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// middlewareNames returns the names of the command middlewares, including those added with UseFor,
+// and of the handler wrappers a command of type t passes through, in the order they run.
+func (mx *mux) middlewareNames(op OpType, t reflect.Type) []string {
+	mx.lock.RLock()
+	defer mx.lock.RUnlock()
+	var names []string
+	for _, mw := range filterMiddleware(op, mx.middlewares[mCmd]) {
+		names = append(names, funcName(mw.fn))
+	}
+	for _, mw := range mx.typed[t] {
+		names = append(names, funcName(mw.fn))
+	}
+	for _, w := range filterMiddleware(op, mx.wrappers) {
+		names = append(names, funcName(w.fn))
+	}
+	return names
+}
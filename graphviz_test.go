@@ -0,0 +1,82 @@
+package dew_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestExportGraphviz(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.ACTION, passThrough)
+	mux.Register(new(userHandler))
+	mux.Group(func(mux dew.Bus) {
+		mux.Use(dew.QUERY, denyAll)
+		mux.Register(new(postHandler))
+	})
+
+	dot := mux.ExportGraphviz()
+
+	if !strings.HasPrefix(dot, "digraph dew {") {
+		t.Fatalf("unexpected graph: %s", dot)
+	}
+	for _, want := range []string{
+		`"dew_test.createUser" [shape=box];`,
+		`"dew_test.findUser" [shape=box];`,
+		`"dew_test.createPost" [shape=box];`,
+		`"dew_test.findPost" [shape=box];`,
+		`"dew_test.createUser" -> "(*dew_test.userHandler).CreateUser" [label="github.com/go-dew/dew_test.passThrough"];`,
+		`"dew_test.findUser" -> "(*dew_test.userHandler).FindUser";`,
+		`"dew_test.findPost" -> "(*dew_test.postHandler).FindPost" [label="github.com/go-dew/dew_test.denyAll"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("expected %s in graph:\n%s", want, dot)
+		}
+	}
+
+	mux.Register(dew.HandlerFunc[updateUser](updateUserHandler))
+	if dot := mux.ExportGraphviz(); !strings.Contains(dot, `"dew_test.updateUser" -> "github.com/go-dew/dew_test.updateUserHandler"`) {
+		t.Errorf("expected function handler in graph:\n%s", dot)
+	}
+}
+
+func updateUserHandler(_ context.Context, command *updateUser) error {
+	return nil
+}
+
+func TestExportGraphviz_UseFor(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.QUERY, passThrough)
+	mux.UseFor(findUser{}, denyAll)
+	mux.Register(new(userHandler))
+
+	dot := mux.ExportGraphviz()
+	want := `"dew_test.findUser" -> "(*dew_test.userHandler).FindUser" [label="github.com/go-dew/dew_test.passThrough\ngithub.com/go-dew/dew_test.denyAll"];`
+	if !strings.Contains(dot, want) {
+		t.Errorf("expected %s in graph:\n%s", want, dot)
+	}
+}
+
+func TestExportGraphviz_Concurrent(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			mux.Use(dew.ALL, passThrough)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			mux.ExportGraphviz()
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,36 @@
+package dew
+
+import (
+	"reflect"
+	"sort"
+)
+
+// HandlerInfo describes a handler registered to the bus.
+type HandlerInfo struct {
+	// Type is the command type.
+	Type reflect.Type
+	// Op is the operation type the handler is registered for.
+	Op OpType
+	// Name is the name of the handler method or function.
+	Name string
+}
+
+// Handlers returns the handlers registered to the bus and its groups, sorted by
+// command type and operation type. A command type handled by several handlers
+// is listed once per handler, in registration order.
+func (mx *mux) Handlers() []HandlerInfo {
+	var infos []HandlerInfo
+	mx.handlers.load().rangeAll(func(key handlerKey, h *handler) bool {
+		for _, hh := range h.all {
+			infos = append(infos, HandlerInfo{Type: key.t, Op: key.op, Name: hh.name})
+		}
+		return true
+	})
+	sort.SliceStable(infos, func(i, j int) bool {
+		if ti, tj := infos[i].Type.String(), infos[j].Type.String(); ti != tj {
+			return ti < tj
+		}
+		return infos[i].Op < infos[j].Op
+	})
+	return infos
+}
@@ -0,0 +1,42 @@
+package dew_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestHandlers(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Group(func(mux dew.Bus) {
+		mux.Register(new(postHandler))
+	})
+	mux.Register(dew.HandlerFunc[findPost](func(ctx context.Context, query *findPost) error {
+		return nil
+	}))
+
+	handlers := mux.Handlers()
+	want := []struct {
+		typ  reflect.Type
+		op   dew.OpType
+		name string
+	}{
+		{reflect.TypeOf(createPost{}), dew.ACTION, "(*dew_test.postHandler).CreatePost"},
+		{reflect.TypeOf(createUser{}), dew.ACTION, "(*dew_test.userHandler).CreateUser"},
+		{reflect.TypeOf(findPost{}), dew.QUERY, "(*dew_test.postHandler).FindPost"},
+		{reflect.TypeOf(findPost{}), dew.QUERY, "github.com/go-dew/dew_test.TestHandlers.func2"},
+		{reflect.TypeOf(findUser{}), dew.QUERY, "(*dew_test.userHandler).FindUser"},
+	}
+	if len(handlers) != len(want) {
+		t.Fatalf("unexpected handlers: %v", handlers)
+	}
+	for i, w := range want {
+		h := handlers[i]
+		if h.Type != w.typ || h.Op != w.op || h.Name != w.name {
+			t.Fatalf("unexpected handler #%d: %v %v %q", i, h.Type, h.Op, h.Name)
+		}
+	}
+}
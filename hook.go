@@ -0,0 +1,22 @@
+package dew
+
+// commandHook is called after a command has been handled.
+type commandHook func(ctx Context, cmd Command, err error)
+
+// OnCommand adds a hook called after each execution of a command of type T,
+// on the bus or any of its groups, with the command and the error returned by its
+// middlewares and handler. Hooks are called in the order they were added.
+func OnCommand[T Command](bus Bus, fn func(ctx Context, cmd *T, err error)) {
+	bus.(*mux).handlers.updateOptions(typeFor[T](), func(o *commandOptions) {
+		o.hooks = append(o.hooks[:len(o.hooks):len(o.hooks)], func(ctx Context, cmd Command, err error) {
+			fn(ctx, cmd.(*T), err)
+		})
+	})
+}
+
+// runHooks calls the hooks added for the command type.
+func (o *commandOptions) runHooks(ctx Context, cmd Command, err error) {
+	for _, fn := range o.hooks {
+		fn(ctx, cmd, err)
+	}
+}
@@ -0,0 +1,59 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestOnCommand(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+
+	var results []string
+	var errs []error
+	dew.OnCommand(mux, func(ctx dew.Context, cmd *createUser, err error) {
+		results = append(results, cmd.Result)
+		errs = append(errs, err)
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}), dew.NewAction(&createPost{Title: "hello"}))
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	if _, err := dew.Dispatch(ctx, &createUser{}); !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 hook calls, got %d", len(results))
+	}
+	if results[0] != "user created" || errs[0] != nil {
+		t.Fatalf("unexpected first call: %q, %v", results[0], errs[0])
+	}
+	if results[1] != "" || !errors.Is(errs[1], errNameRequired) {
+		t.Fatalf("unexpected second call: %q, %v", results[1], errs[1])
+	}
+}
+
+func TestOnCommand_Group(t *testing.T) {
+	mux := dew.New()
+	var calls []string
+	dew.OnCommand(mux, func(ctx dew.Context, cmd *findUser, err error) {
+		calls = append(calls, "first")
+	})
+	mux.Group(func(mux dew.Bus) {
+		mux.Register(new(userHandler))
+		dew.OnCommand(mux, func(ctx dew.Context, cmd *findUser, err error) {
+			calls = append(calls, "second")
+		})
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Fatalf("unexpected hook calls: %v", calls)
+	}
+}
@@ -0,0 +1,170 @@
+package dew
+
+import (
+	"container/list"
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultIdempotencySize is the number of results kept by the Idempotency middleware by default.
+const DefaultIdempotencySize = 10000
+
+// Idempotent is implemented by commands that must be executed at most once per key.
+type Idempotent interface {
+	// IdempotencyKey returns the key identifying the command execution.
+	IdempotencyKey() string
+}
+
+// IdempotencyOption configures the Idempotency middleware.
+type IdempotencyOption func(s *idempotencyStore)
+
+// WithIdempotencySize sets the number of results kept, evicting the least recently used one.
+// If size is zero or negative, the number of results is unbounded.
+func WithIdempotencySize(size int) IdempotencyOption {
+	return func(s *idempotencyStore) {
+		s.results.size = size
+	}
+}
+
+// WithIdempotencyTTL sets the time the results are kept for, measured with the clock of the bus.
+// If ttl is zero or negative, the results are kept until evicted.
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyOption {
+	return func(s *idempotencyStore) {
+		s.ttl = ttl
+	}
+}
+
+// idempotencyStore holds the results of the executed commands and the executions in flight.
+type idempotencyStore struct {
+	results memoStore
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	inflight map[memoKey]*idempotentCall
+	// onWait is called when a command starts waiting for an execution in flight, if set.
+	onWait func()
+}
+
+// idempotentCall is an execution in flight. done is closed once it has returned.
+type idempotentCall struct {
+	done chan struct{}
+}
+
+// acquire returns the cached result of the command, or registers the execution of the command
+// once no other execution with the same key is in flight.
+func (s *idempotencyStore) acquire(ctx context.Context, key memoKey, now func() time.Time) (reflect.Value, *idempotentCall, error) {
+	for {
+		if result, ok := s.results.load(key, now()); ok {
+			return result, nil, nil
+		}
+		s.mu.Lock()
+		call, ok := s.inflight[key]
+		if !ok {
+			// Check again, the execution in flight may have returned since.
+			if result, ok := s.results.load(key, now()); ok {
+				s.mu.Unlock()
+				return result, nil, nil
+			}
+			call = &idempotentCall{done: make(chan struct{})}
+			s.inflight[key] = call
+			s.mu.Unlock()
+			return reflect.Value{}, call, nil
+		}
+		s.mu.Unlock()
+		if s.onWait != nil {
+			s.onWait()
+		}
+		// Wait for the execution in flight, then replay its result,
+		// or execute the command if it failed.
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return reflect.Value{}, nil, ctx.Err()
+		}
+	}
+}
+
+// release ends the execution, storing its result if it succeeded.
+func (s *idempotencyStore) release(key memoKey, call *idempotentCall, result reflect.Value, now time.Time) {
+	if result.IsValid() {
+		var expires time.Time
+		if s.ttl > 0 {
+			expires = now.Add(s.ttl)
+		}
+		s.results.store(key, result, expires)
+	}
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(call.done)
+}
+
+// Idempotency returns a middleware that executes Idempotent commands at most once per key.
+// Commands are keyed by type and IdempotencyKey.
+// The command is cached after the handler has succeeded; a later command of the same type
+// and key is not executed and receives a deep copy of the cached command instead. A command
+// issued while another with the same type and key is executing waits for it, and is only
+// executed if it failed. At most DefaultIdempotencySize results are kept unless changed
+// with WithIdempotencySize, and they do not expire unless WithIdempotencyTTL is set.
+// Commands that are not Idempotent or whose key is empty are executed as usual.
+func Idempotency(opts ...IdempotencyOption) func(next Middleware) Middleware {
+	s := &idempotencyStore{
+		results:  memoStore{size: DefaultIdempotencySize, entries: make(map[memoKey]*list.Element), lru: list.New()},
+		inflight: make(map[memoKey]*idempotentCall),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			cmd, ok := ctx.Command().(Idempotent)
+			if !ok {
+				return next.Handle(ctx)
+			}
+			k := cmd.IdempotencyKey()
+			if k == "" {
+				return next.Handle(ctx)
+			}
+			v := reflect.ValueOf(cmd).Elem()
+			key := memoKey{t: v.Type(), identity: k}
+			clock := clockFrom(ctx.Context())
+			cached, call, err := s.acquire(ctx.Context(), key, clock.Now)
+			if err != nil {
+				return err
+			}
+			if call == nil {
+				deepCopy(v, cached)
+				if f, ok := ctx.Context().Value(replayKey{}).(*atomic.Bool); ok {
+					f.Store(true)
+				}
+				return nil
+			}
+			var result reflect.Value
+			defer func() { s.release(key, call, result, clock.Now()) }()
+			if err := next.Handle(ctx); err != nil {
+				return err
+			}
+			result = reflect.New(v.Type()).Elem()
+			deepCopy(result, v)
+			return nil
+		})
+	}
+}
+
+type replayKey struct{}
+
+// WithReplayTracking returns a new context that records whether a command dispatched
+// with it was served by the Idempotency middleware instead of being executed.
+func WithReplayTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayKey{}, new(atomic.Bool))
+}
+
+// WasReplay reports whether a command dispatched with the context, created with
+// WithReplayTracking, was a replay of a previously executed command.
+func WasReplay(ctx context.Context) bool {
+	f, ok := ctx.Value(replayKey{}).(*atomic.Bool)
+	return ok && f.Load()
+}
@@ -0,0 +1,257 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+type chargeCard struct {
+	RequestID string
+	Amount    int
+	Result    int
+}
+
+func (chargeCard) Validate(context.Context) error { return nil }
+
+func (c chargeCard) IdempotencyKey() string { return c.RequestID }
+
+func TestIdempotency(t *testing.T) {
+	charges := 0
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.Idempotency())
+	mux.Register(dew.HandlerFunc[chargeCard](func(ctx context.Context, cmd *chargeCard) error {
+		charges++
+		cmd.Result = charges
+		return nil
+	}))
+	bus := dew.NewContext(context.Background(), mux)
+
+	ctx := dew.WithReplayTracking(bus)
+	action, err := dew.Dispatch(ctx, &chargeCard{RequestID: "req-1", Amount: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dew.WasReplay(ctx) {
+		t.Fatal("expected the first dispatch not to be a replay")
+	}
+	if action.Result != 1 {
+		t.Fatalf("unexpected result: %d", action.Result)
+	}
+
+	ctx = dew.WithReplayTracking(bus)
+	action, err = dew.Dispatch(ctx, &chargeCard{RequestID: "req-1", Amount: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !dew.WasReplay(ctx) {
+		t.Fatal("expected the second dispatch to be a replay")
+	}
+	if action.Result != 1 || charges != 1 {
+		t.Fatalf("expected the cached result, got %d after %d charges", action.Result, charges)
+	}
+
+	ctx = dew.WithReplayTracking(bus)
+	if action, err = dew.Dispatch(ctx, &chargeCard{RequestID: "req-2", Amount: 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dew.WasReplay(ctx) || action.Result != 2 {
+		t.Fatalf("expected a fresh execution, got result %d", action.Result)
+	}
+}
+
+type payInvoice struct {
+	RequestID string
+	Invoice   int
+	Result    int
+}
+
+func (payInvoice) Validate(context.Context) error { return nil }
+
+func (c payInvoice) IdempotencyKey() string { return c.RequestID }
+
+func (c payInvoice) CacheKey() string { return "invoices" }
+
+func TestIdempotency_IdempotencyKey(t *testing.T) {
+	payments := 0
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.Idempotency())
+	mux.Register(dew.HandlerFunc[payInvoice](func(ctx context.Context, cmd *payInvoice) error {
+		payments++
+		cmd.Result = cmd.Invoice
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// the key is the IdempotencyKey, even if the command has a CacheKey
+	if _, err := dew.Dispatch(ctx, &payInvoice{RequestID: "a", Invoice: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	action, err := dew.Dispatch(ctx, &payInvoice{RequestID: "b", Invoice: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payments != 2 || action.Result != 2 {
+		t.Fatalf("expected a second payment, got result %d after %d payments", action.Result, payments)
+	}
+}
+
+func TestIdempotency_Concurrent(t *testing.T) {
+	// the retries of the client arrive while the first charge is executing
+	const retries = 5
+	var charges atomic.Int32
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	waiting := make(chan struct{}, retries)
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.Idempotency(dew.WithIdempotencyWaitHook(func() { waiting <- struct{}{} })))
+	mux.Register(dew.HandlerFunc[chargeCard](func(ctx context.Context, cmd *chargeCard) error {
+		if charges.Add(1) == 1 {
+			close(started)
+			<-unblock
+		}
+		cmd.Result = 42
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	var wg sync.WaitGroup
+	results := make(chan int, retries+1)
+	dispatch := func() {
+		defer wg.Done()
+		action, err := dew.Dispatch(ctx, &chargeCard{RequestID: "req-1", Amount: 100})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		results <- action.Result
+	}
+	wg.Add(1)
+	go dispatch()
+	<-started
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go dispatch()
+	}
+	for i := 0; i < retries; i++ {
+		<-waiting
+	}
+	close(unblock)
+	wg.Wait()
+	close(results)
+
+	if n := charges.Load(); n != 1 {
+		t.Fatalf("expected a single charge, got %d", n)
+	}
+	for result := range results {
+		if result != 42 {
+			t.Fatalf("unexpected result: %d", result)
+		}
+	}
+}
+
+func TestIdempotency_Failure(t *testing.T) {
+	errDeclined := errors.New("declined")
+	charges := 0
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.Idempotency())
+	mux.Register(dew.HandlerFunc[chargeCard](func(ctx context.Context, cmd *chargeCard) error {
+		charges++
+		if charges == 1 {
+			return errDeclined
+		}
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// failed commands are executed again
+	if _, err := dew.Dispatch(ctx, &chargeCard{RequestID: "req-1"}); !errors.Is(err, errDeclined) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.Dispatch(ctx, &chargeCard{RequestID: "req-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if charges != 2 {
+		t.Fatalf("unexpected number of charges: %d", charges)
+	}
+}
+
+func TestIdempotency_Bounded(t *testing.T) {
+	charges := 0
+	clock := dewtest.NewFakeClock(time.Now())
+	mux := dew.New(dew.WithClock(clock))
+	mux.Use(dew.ACTION, dew.Idempotency(dew.WithIdempotencySize(2), dew.WithIdempotencyTTL(time.Hour)))
+	mux.Register(dew.HandlerFunc[chargeCard](func(ctx context.Context, cmd *chargeCard) error {
+		charges++
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	charge := func(id string) bool {
+		ctx := dew.WithReplayTracking(ctx)
+		if _, err := dew.Dispatch(ctx, &chargeCard{RequestID: id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return dew.WasReplay(ctx)
+	}
+
+	charge("req-1")
+	charge("req-2")
+	charge("req-3")
+	// req-1 was evicted by req-3
+	if charge("req-1") || !charge("req-3") {
+		t.Fatal("expected the least recently used result to be evicted")
+	}
+
+	// results expire
+	clock.Advance(time.Hour)
+	if charge("req-3") {
+		t.Fatal("expected the result to expire")
+	}
+	if charges != 5 {
+		t.Fatalf("unexpected number of charges: %d", charges)
+	}
+}
+
+type bookSeats struct {
+	RequestID string
+	Seats     []string
+}
+
+func (bookSeats) Validate(context.Context) error { return nil }
+
+func (c bookSeats) IdempotencyKey() string { return c.RequestID }
+
+func TestIdempotency_DeepCopy(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.Idempotency())
+	mux.Register(dew.HandlerFunc[bookSeats](func(ctx context.Context, cmd *bookSeats) error {
+		cmd.Seats = []string{"1A", "1B"}
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	first, err := dew.Dispatch(ctx, &bookSeats{RequestID: "req-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first.Seats[0] = "changed"
+	second, err := dew.Dispatch(ctx, &bookSeats{RequestID: "req-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second.Seats[1] = "changed"
+	third, err := dew.Dispatch(ctx, &bookSeats{RequestID: "req-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if third.Seats[0] != "1A" || third.Seats[1] != "1B" {
+		t.Fatalf("the replayed command shares its seats: %v", third.Seats)
+	}
+}
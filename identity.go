@@ -0,0 +1,27 @@
+package dew
+
+import "context"
+
+// Identity identifies the request a command is executed for.
+type Identity struct {
+	// TenantID identifies the tenant of a multi-tenant system.
+	TenantID string
+	// UserID identifies the user issuing the request.
+	UserID string
+	// RequestID identifies the request, e.g. for tracing.
+	RequestID string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a new context with the identity of the request.
+// The Audit middleware records it with each entry.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity set with WithIdentity.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
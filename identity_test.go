@@ -0,0 +1,49 @@
+package dew_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestIdentity(t *testing.T) {
+	identity := dew.Identity{TenantID: "acme", UserID: "u1", RequestID: "r1"}
+	var got dew.Identity
+	sink := &fakeAuditSink{}
+
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.Audit(sink))
+	mux.Register(dew.HandlerFunc[createUser](
+		func(ctx context.Context, action *createUser) error {
+			var ok bool
+			if got, ok = dew.IdentityFromContext(ctx); !ok {
+				t.Error("expected an identity")
+			}
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, ok := dew.IdentityFromContext(ctx); ok {
+		t.Fatal("unexpected identity")
+	}
+
+	testRunDispatch(t, dew.WithIdentity(ctx, identity), dew.NewAction(&createUser{Name: "john"}))
+	if got != identity {
+		t.Fatalf("unexpected identity: %+v", got)
+	}
+	if len(sink.entries) != 1 {
+		t.Fatalf("unexpected entries: %+v", sink.entries)
+	}
+	if entry := sink.entries[0]; entry.Identity != identity || entry.Actor != "u1" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	// the actor set with WithActor takes precedence
+	ctx = dew.WithActor(dew.WithIdentity(ctx, identity), "admin")
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	if entry := sink.entries[1]; entry.Identity != identity || entry.Actor != "admin" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+}
@@ -0,0 +1,105 @@
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-dew/dew"
+)
+
+// ValidationError lists the schema violations of a payload.
+// It matches dew.ErrValidationFailed with errors.Is.
+type ValidationError struct {
+	// Name is the name of the command.
+	Name string
+	// Violations describes each violation, prefixed by its JSON path.
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", dew.ErrValidationFailed, e.Name, strings.Join(e.Violations, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return dew.ErrValidationFailed
+}
+
+// Validator holds the schemas and the actions registered by name.
+// Each Validator is independent, so several buses or tests do not share their commands.
+type Validator struct {
+	mu       sync.RWMutex
+	schemas  map[string]*Schema
+	commands map[string]func(ctx context.Context, payload []byte) error
+}
+
+// NewValidator creates an empty Validator.
+func NewValidator() *Validator {
+	return &Validator{
+		schemas:  make(map[string]*Schema),
+		commands: make(map[string]func(ctx context.Context, payload []byte) error),
+	}
+}
+
+// RegisterSchema registers the JSON Schema for the command name.
+// It fails if the schema uses a keyword that is not supported.
+func (v *Validator) RegisterSchema(name string, schema []byte) error {
+	s, err := Compile(schema)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.schemas[name] = s
+	return nil
+}
+
+// RegisterAction registers the action type T under the name for DispatchJSON.
+func RegisterAction[T dew.Action](v *Validator, name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.commands[name] = func(ctx context.Context, payload []byte) error {
+		var action T
+		if err := json.Unmarshal(payload, &action); err != nil {
+			return err
+		}
+		_, err := dew.Dispatch(ctx, &action)
+		return err
+	}
+}
+
+// Validate validates the payload against the schema registered for the name.
+// Payloads of commands without a schema are considered valid.
+func (v *Validator) Validate(name string, payload []byte) error {
+	v.mu.RLock()
+	s, ok := v.schemas[name]
+	v.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	violations, err := s.Validate(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", dew.ErrValidationFailed, err)
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Name: name, Violations: violations}
+	}
+	return nil
+}
+
+// DispatchJSON validates the payload against the schema registered for the name,
+// decodes it into the action registered with RegisterAction, and dispatches it.
+func (v *Validator) DispatchJSON(ctx context.Context, name string, payload []byte) error {
+	v.mu.RLock()
+	dispatch, ok := v.commands[name]
+	v.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("jsonschema: unknown command %q", name)
+	}
+	if err := v.Validate(name, payload); err != nil {
+		return err
+	}
+	return dispatch(ctx, payload)
+}
@@ -0,0 +1,145 @@
+package jsonschema_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/jsonschema"
+)
+
+type createUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+	Role string `json:"role"`
+}
+
+func (createUser) Validate(context.Context) error { return nil }
+
+const createUserSchema = `{
+	"type": "object",
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"role": {"enum": ["admin", "member"]}
+	}
+}`
+
+func TestDispatchJSON(t *testing.T) {
+	v := jsonschema.NewValidator()
+	if err := v.RegisterSchema("user.create", []byte(createUserSchema)); err != nil {
+		t.Fatal(err)
+	}
+	jsonschema.RegisterAction[createUser](v, "user.create")
+
+	var created []createUser
+	bus := dew.New()
+	bus.Register(dew.HandlerFunc[createUser](
+		func(ctx context.Context, command *createUser) error {
+			created = append(created, *command)
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), bus)
+
+	err := v.DispatchJSON(ctx, "user.create", []byte(`{"name": "john", "age": 30, "role": "admin"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0].Name != "john" || created[0].Age != 30 {
+		t.Fatalf("unexpected commands: %v", created)
+	}
+
+	err = v.DispatchJSON(ctx, "user.create", []byte(`{"name": "", "age": 1.5, "role": "root", "extra": true}`))
+	if !errors.Is(err, dew.ErrValidationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a validation error, got: %v", err)
+	}
+	for _, want := range []string{
+		`$: unexpected property "extra"`,
+		"$.age: expected integer",
+		"$.name: length must be >= 1",
+		"$.role: value is not one of the allowed values",
+	} {
+		found := false
+		for _, v := range verr.Violations {
+			if strings.HasPrefix(v, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected violation %q in %v", want, verr.Violations)
+		}
+	}
+	if len(created) != 1 {
+		t.Fatalf("invalid payload should not be dispatched: %v", created)
+	}
+
+	err = v.DispatchJSON(ctx, "user.create", []byte(`{"age": 1}`))
+	if !errors.Is(err, dew.ErrValidationFailed) || !strings.Contains(err.Error(), `missing required property "name"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := v.DispatchJSON(ctx, "user.delete", []byte(`{}`)); err == nil {
+		t.Fatal("expected an unknown command error, got nil")
+	}
+}
+
+func TestRegisterSchema_UnsupportedKeyword(t *testing.T) {
+	v := jsonschema.NewValidator()
+	for schema, want := range map[string]string{
+		`{"$ref": "#/definitions/user"}`:                                   `"$ref" at $`,
+		`{"oneOf": [{"type": "string"}]}`:                                  `"oneOf" at $`,
+		`{"properties": {"email": {"type": "string", "format": "email"}}}`: `"format" at $.email`,
+		`{"properties": {"tags": {"items": {"minItems": 1}}}}`:             `"minItems" at $.tags[]`,
+	} {
+		err := v.RegisterSchema("user.create", []byte(schema))
+		if err == nil || !strings.Contains(err.Error(), "unsupported keyword "+want) {
+			t.Errorf("unexpected error for %s: %v", schema, err)
+		}
+	}
+
+	// annotations are accepted
+	err := v.RegisterSchema("user.create", []byte(`{"title": "User", "description": "A user", "type": "object"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidator_Isolated(t *testing.T) {
+	v1, v2 := jsonschema.NewValidator(), jsonschema.NewValidator()
+	if err := v1.RegisterSchema("user.create", []byte(createUserSchema)); err != nil {
+		t.Fatal(err)
+	}
+	jsonschema.RegisterAction[createUser](v1, "user.create")
+
+	if err := v2.Validate("user.create", []byte(`{}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := dew.NewContext(context.Background(), dew.New())
+	if err := v2.DispatchJSON(ctx, "user.create", []byte(`{}`)); err == nil {
+		t.Fatal("expected an unknown command error, got nil")
+	}
+}
+
+func TestValidate_NumericEnum(t *testing.T) {
+	v := jsonschema.NewValidator()
+	if err := v.RegisterSchema("order.rate", []byte(`{"properties": {"stars": {"enum": [1, 100, [2]]}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	for _, payload := range []string{`{"stars": 1.0}`, `{"stars": 1e2}`, `{"stars": [2.0]}`} {
+		if err := v.Validate("order.rate", []byte(payload)); err != nil {
+			t.Errorf("unexpected error for %s: %v", payload, err)
+		}
+	}
+	if err := v.Validate("order.rate", []byte(`{"stars": 2}`)); !errors.Is(err, dew.ErrValidationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
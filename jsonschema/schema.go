@@ -0,0 +1,270 @@
+// Package jsonschema validates JSON payloads of commands against JSON Schemas
+// before they are decoded and dispatched by name.
+//
+// It supports the commonly used subset of JSON Schema: type, properties, required,
+// additionalProperties, items, enum, minLength, maxLength, pattern, minimum and maximum.
+// Schemas using other keywords, e.g. $ref, oneOf or format, are rejected when compiled,
+// except for annotations such as title and description.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	Type                 string             `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+
+	pattern *regexp.Regexp
+}
+
+// keywords are the keywords accepted in a schema: the supported ones and the annotations.
+var keywords = map[string]bool{
+	"type": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "enum": true, "minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true,
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// Compile parses the JSON Schema document.
+// It fails if the schema uses a keyword that is not supported.
+func Compile(schema []byte) (*Schema, error) {
+	if err := checkKeywords("$", schema); err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("jsonschema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// checkKeywords reports the first unsupported keyword of the schema and its subschemas.
+func checkKeywords(path string, schema []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(schema, &raw); err != nil {
+		return fmt.Errorf("jsonschema: %w", err)
+	}
+	for _, name := range sortedKeys(raw) {
+		if !keywords[name] {
+			return fmt.Errorf("jsonschema: unsupported keyword %q at %s", name, path)
+		}
+	}
+	if props, ok := raw["properties"]; ok {
+		var subs map[string]json.RawMessage
+		if err := json.Unmarshal(props, &subs); err != nil {
+			return fmt.Errorf("jsonschema: %w", err)
+		}
+		for _, name := range sortedKeys(subs) {
+			if err := checkKeywords(path+"."+name, subs[name]); err != nil {
+				return err
+			}
+		}
+	}
+	if items, ok := raw["items"]; ok {
+		return checkKeywords(path+"[]", items)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map in order, so errors are deterministic.
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid pattern: %w", err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Validate validates the JSON payload and returns the violations found.
+func (s *Schema) Validate(payload []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("jsonschema: %w", err)
+	}
+	var violations []string
+	s.validate("$", v, &violations)
+	return violations, nil
+}
+
+func (s *Schema) validate(path string, v any, violations *[]string) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.Type != "" && !hasType(v, s.Type) {
+		report("expected %s, got %s", s.Type, typeOf(v))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		report("value is not one of the allowed values")
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			report("length must be >= %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			report("length must be <= %d", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			report("does not match pattern %q", s.Pattern)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			report("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			report("must be <= %v", *s.Maximum)
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if p, ok := s.Properties[name]; ok {
+				p.validate(path+"."+name, v[name], violations)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				report("unexpected property %q", name)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	}
+}
+
+func hasType(v any, typ string) bool {
+	switch typ {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	default:
+		return typeOf(v) == typ
+	}
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func inEnum(v any, enum []any) bool {
+	for _, e := range enum {
+		if equal(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal reports whether the decoded payload value equals the enum value.
+// Numbers are compared numerically, so 1.0 equals 1 and 1e2 equals 100.
+func equal(v, e any) bool {
+	switch v := v.(type) {
+	case json.Number:
+		want, ok := e.(float64)
+		if !ok {
+			return false
+		}
+		f, err := v.Float64()
+		return err == nil && f == want
+	case []any:
+		want, ok := e.([]any)
+		if !ok || len(want) != len(v) {
+			return false
+		}
+		for i := range v {
+			if !equal(v[i], want[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		want, ok := e.(map[string]any)
+		if !ok || len(want) != len(v) {
+			return false
+		}
+		for k, x := range v {
+			if y, ok := want[k]; !ok || !equal(x, y) {
+				return false
+			}
+		}
+		return true
+	default:
+		return v == e
+	}
+}
@@ -0,0 +1,35 @@
+package dew
+
+import "context"
+
+// Key is a typed context key. Keys are compared by identity,
+// so keys created by different packages never collide even if they share a name.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a new typed context key. The name is only used for debugging.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return "dew key " + k.name
+}
+
+// WithTypedValue returns the Context with the value associated to the typed key.
+func WithTypedValue[T any](ctx Context, key *Key[T], v T) Context {
+	return ctx.WithValue(key, v)
+}
+
+// ContextWithTypedValue returns a copy of the context.Context with the value associated to the typed key.
+func ContextWithTypedValue[T any](ctx context.Context, key *Key[T], v T) context.Context {
+	return context.WithValue(ctx, key, v)
+}
+
+// TypedValue returns the value associated to the typed key.
+func TypedValue[T any](ctx context.Context, key *Key[T]) (T, bool) {
+	v, ok := ctx.Value(key).(T)
+	return v, ok
+}
@@ -0,0 +1,55 @@
+package dew_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+// keys as they would be declared by two unrelated packages
+var (
+	authUserKey  = dew.NewKey[string]("user")
+	auditUserKey = dew.NewKey[int]("user")
+	tenantKey    = dew.NewKey[string]("user")
+)
+
+func TestTypedValue(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			ctx = dew.WithTypedValue(ctx, authUserKey, "john")
+			ctx = dew.WithTypedValue(ctx, auditUserKey, 42)
+			return next.Handle(ctx)
+		})
+	})
+
+	var (
+		authUser   string
+		auditUser  int
+		tenantUser string
+		tenantOK   bool
+	)
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			authUser, _ = dew.TypedValue(ctx, authUserKey)
+			auditUser, _ = dew.TypedValue(ctx, auditUserKey)
+			tenantUser, tenantOK = dew.TypedValue(ctx, tenantKey)
+			return nil
+		},
+	))
+
+	ctx := dew.NewContext(context.Background(), mux)
+	ctx = dew.ContextWithTypedValue(ctx, tenantKey, "acme")
+	testRunQuery(t, ctx, &findUser{ID: 1})
+
+	if authUser != "john" {
+		t.Errorf("unexpected auth user: %s", authUser)
+	}
+	if auditUser != 42 {
+		t.Errorf("unexpected audit user: %d", auditUser)
+	}
+	if !tenantOK || tenantUser != "acme" {
+		t.Errorf("unexpected tenant: %s", tenantUser)
+	}
+}
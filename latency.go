@@ -0,0 +1,146 @@
+package dew
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Percentiles summarizes the latencies recorded for a command type.
+type Percentiles struct {
+	// Count is the number of samples the percentiles are computed from.
+	Count int
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// LatencyTracker records handler latencies per command type over a sliding window
+// of the most recent samples.
+type LatencyTracker struct {
+	window int
+	clock  Clock
+
+	mu      sync.Mutex
+	samples map[reflect.Type]*latencyRing
+}
+
+// latencyRing is a fixed-size ring buffer of latency samples.
+type latencyRing struct {
+	buf  []time.Duration
+	next int
+	full bool
+}
+
+func (r *latencyRing) add(d time.Duration) {
+	r.buf[r.next] = d
+	r.next++
+	if r.next == len(r.buf) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+func (r *latencyRing) values() []time.Duration {
+	if r.full {
+		return append([]time.Duration(nil), r.buf...)
+	}
+	return append([]time.Duration(nil), r.buf[:r.next]...)
+}
+
+// NewLatencyTracker creates a tracker keeping the last window samples per command type.
+// If clock is nil, the system clock is used.
+func NewLatencyTracker(window int, clock Clock) *LatencyTracker {
+	if window <= 0 {
+		window = 1024
+	}
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &LatencyTracker{
+		window:  window,
+		clock:   clock,
+		samples: make(map[reflect.Type]*latencyRing),
+	}
+}
+
+// Middleware returns a middleware measuring the duration of the handler.
+// Register it with UseHandlerWrapper to measure the handler only:
+//
+//	bus.UseHandlerWrapper(dew.ALL, tracker.Middleware)
+func (lt *LatencyTracker) Middleware(next Middleware) Middleware {
+	return MiddlewareFunc(func(ctx Context) error {
+		start := lt.clock.Now()
+		err := next.Handle(ctx)
+		if cmd := ctx.Command(); cmd != nil {
+			lt.Record(reflect.TypeOf(cmd).Elem(), lt.clock.Now().Sub(start))
+		}
+		return err
+	})
+}
+
+// Record adds a latency sample for the command type.
+func (lt *LatencyTracker) Record(cmdType reflect.Type, d time.Duration) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	r, ok := lt.samples[cmdType]
+	if !ok {
+		r = &latencyRing{buf: make([]time.Duration, lt.window)}
+		lt.samples[cmdType] = r
+	}
+	r.add(d)
+}
+
+// WithLatencyTracking makes the bus record the handler latencies per command type over a sliding
+// window of the most recent samples, reported by LatencyStats. The handlers are measured with the
+// clock of the bus, by a handler wrapper inherited by the groups like the other wrappers.
+// If window is not positive, the last 1024 samples are kept.
+func WithLatencyTracking(window int) Option {
+	return func(mx *mux) {
+		lt := NewLatencyTracker(window, busClock{mx})
+		mx.handlers.latency = lt
+		mx.UseHandlerWrapper(ALL, lt.Middleware)
+	}
+}
+
+// LatencyStats returns the handler latency percentiles for the command type,
+// or zero percentiles if the bus is not created with WithLatencyTracking.
+func (mx *mux) LatencyStats(cmdType reflect.Type) Percentiles {
+	if lt := mx.handlers.latency; lt != nil {
+		return lt.LatencyStats(cmdType)
+	}
+	return Percentiles{}
+}
+
+// LatencyStats returns the latency percentiles for the command type.
+func (lt *LatencyTracker) LatencyStats(cmdType reflect.Type) Percentiles {
+	lt.mu.Lock()
+	r, ok := lt.samples[cmdType]
+	var values []time.Duration
+	if ok {
+		values = r.values()
+	}
+	lt.mu.Unlock()
+
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return Percentiles{
+		Count: len(values),
+		P50:   percentile(values, 0.50),
+		P95:   percentile(values, 0.95),
+		P99:   percentile(values, 0.99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
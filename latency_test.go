@@ -0,0 +1,84 @@
+package dew_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	clock := new(dewtest.FakeClock)
+	tracker := dew.NewLatencyTracker(100, clock)
+
+	mux := dew.New()
+	mux.UseHandlerWrapper(dew.ALL, tracker.Middleware)
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			// the query ID is the latency in milliseconds
+			clock.Advance(time.Duration(query.ID) * time.Millisecond)
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	for i := 1; i <= 100; i++ {
+		testRunQuery(t, ctx, &findUser{ID: i})
+	}
+
+	stats := tracker.LatencyStats(reflect.TypeOf(findUser{}))
+	if stats.Count != 100 {
+		t.Fatalf("unexpected count: %d", stats.Count)
+	}
+	if stats.P50 != 50*time.Millisecond {
+		t.Errorf("unexpected p50: %v", stats.P50)
+	}
+	if stats.P95 != 95*time.Millisecond {
+		t.Errorf("unexpected p95: %v", stats.P95)
+	}
+	if stats.P99 != 99*time.Millisecond {
+		t.Errorf("unexpected p99: %v", stats.P99)
+	}
+
+	// the window only keeps the most recent samples
+	for i := 0; i < 100; i++ {
+		testRunQuery(t, ctx, &findUser{ID: 1})
+	}
+	if stats := tracker.LatencyStats(reflect.TypeOf(findUser{})); stats.P99 != time.Millisecond {
+		t.Errorf("unexpected p99: %v", stats.P99)
+	}
+
+	if stats := tracker.LatencyStats(reflect.TypeOf(findPost{})); stats.Count != 0 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+}
+
+func TestWithLatencyTracking(t *testing.T) {
+	clock := new(dewtest.FakeClock)
+	mux := dew.New(dew.WithLatencyTracking(10), dew.WithClock(clock))
+	mux.Group(func(mux dew.Bus) {
+		mux.Register(dew.HandlerFunc[findUser](
+			func(ctx context.Context, query *findUser) error {
+				clock.Advance(time.Duration(query.ID) * time.Millisecond)
+				return nil
+			},
+		))
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	for i := 1; i <= 20; i++ {
+		testRunQuery(t, ctx, &findUser{ID: i})
+	}
+
+	stats := mux.LatencyStats(reflect.TypeOf(findUser{}))
+	if stats.Count != 10 || stats.P50 != 15*time.Millisecond || stats.P99 != 20*time.Millisecond {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	if stats := dew.New().LatencyStats(reflect.TypeOf(findUser{})); stats.Count != 0 {
+		t.Fatalf("unexpected stats without tracking: %+v", stats)
+	}
+}
@@ -0,0 +1,63 @@
+package dew
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrConcurrencyLimited is returned when a handler is at its concurrency limit
+	// and the LimitReject policy is used.
+	ErrConcurrencyLimited = errors.New("concurrency limited")
+)
+
+// LimitPolicy defines what happens when a handler is at its concurrency limit.
+type LimitPolicy uint8
+
+const (
+	// LimitWait waits for a running execution to finish or for the context to be done.
+	LimitWait LimitPolicy = iota
+	// LimitReject fails immediately with ErrConcurrencyLimited.
+	LimitReject
+)
+
+// limiter is a counting semaphore limiting the concurrent executions of a handler.
+type limiter struct {
+	sem    chan struct{}
+	policy LimitPolicy
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	default:
+	}
+	if l.policy == LimitReject {
+		return ErrConcurrencyLimited
+	}
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) release() {
+	<-l.sem
+}
+
+// LimitConcurrency limits the concurrent executions of the handler for T to max
+// across all requests. The policy defines what happens when the limit is reached.
+// If max is zero or negative, the executions are unlimited, removing any previous limit.
+func LimitConcurrency[T Command](bus Bus, max int, policy LimitPolicy) {
+	if max <= 0 {
+		bus.(*mux).handlers.limits.Delete(typeFor[T]())
+		return
+	}
+	bus.(*mux).handlers.limits.Store(typeFor[T](), &limiter{
+		sem:    make(chan struct{}, max),
+		policy: policy,
+	})
+}
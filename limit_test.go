@@ -0,0 +1,125 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+)
+
+func TestLimitConcurrency(t *testing.T) {
+	var running, maxRunning, postRunning, maxPostRunning atomic.Int32
+	track := func(cur, max *atomic.Int32) func() {
+		n := cur.Add(1)
+		for {
+			m := max.Load()
+			if n <= m || max.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		return func() { cur.Add(-1) }
+	}
+
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			defer track(&running, &maxRunning)()
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+	))
+	mux.Register(dew.HandlerFunc[findPost](
+		func(ctx context.Context, query *findPost) error {
+			defer track(&postRunning, &maxPostRunning)()
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+	))
+	dew.LimitConcurrency[findUser](mux, 2, dew.LimitWait)
+	ctx := dew.NewContext(context.Background(), mux)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := dew.Query(ctx, &findUser{ID: 1}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := dew.Query(ctx, &findPost{ID: 1}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning.Load() != 2 {
+		t.Fatalf("unexpected concurrent executions: %d", maxRunning.Load())
+	}
+	if maxPostRunning.Load() <= 2 {
+		t.Fatalf("expected other command types to be unaffected: %d", maxPostRunning.Load())
+	}
+}
+
+func TestLimitConcurrency_Reject(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			close(started)
+			<-release
+			return nil
+		},
+	))
+	dew.LimitConcurrency[findUser](mux, 1, dew.LimitReject)
+	ctx := dew.NewContext(context.Background(), mux)
+
+	done := make(chan error)
+	go func() {
+		_, err := dew.Query(ctx, &findUser{ID: 1})
+		done <- err
+	}()
+	<-started
+
+	if _, err := dew.Query(ctx, &findUser{ID: 2}); !errors.Is(err, dew.ErrConcurrencyLimited) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimitConcurrency_Unlimited(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	for _, policy := range []dew.LimitPolicy{dew.LimitWait, dew.LimitReject} {
+		for _, max := range []int{0, -1} {
+			dew.LimitConcurrency[findUser](mux, max, policy)
+			qctx, cancel := context.WithTimeout(ctx, time.Second)
+			_, err := dew.Query(qctx, &findUser{ID: 1})
+			cancel()
+			if err != nil {
+				t.Fatalf("unexpected error with a limit of %d: %v", max, err)
+			}
+		}
+	}
+
+	// a zero limit removes the previous limit
+	dew.LimitConcurrency[findUser](mux, 1, dew.LimitReject)
+	dew.LimitConcurrency[findUser](mux, 0, dew.LimitReject)
+	if err := dew.QueryAsync(ctx, dew.NewQuery(&findUser{ID: 1}), dew.NewQuery(&findUser{ID: 1})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
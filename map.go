@@ -1,10 +1,199 @@
 package dew
 
 import (
+	"context"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// registry holds the current set of handlers shared by a mux and its groups.
+type registry struct {
+	current atomic.Pointer[handlerSet]
+	// write serializes the changes to the handler set.
+	write sync.Mutex
+	// limits holds the concurrency limiters by command type.
+	limits sync.Map
+	// options holds the *commandOptions by command type.
+	options sync.Map
+	// closer tracks the executions in flight for Close.
+	closer closer
+	// strict rejects the registration of already handled command types.
+	strict bool
+	// opName sets OpNameKey during the executions.
+	opName bool
+	// clock is the clock set with WithClock, if any.
+	clock Clock
+	// fallback executes the commands without a registered handler, if set.
+	fallback func(ctx context.Context, cmd Command) error
+	// reentry is the policy consulted before re-entrant executions, if set.
+	reentry atomic.Pointer[reentryPolicy]
+	// stats holds the counters reported by RuntimeStats.
+	stats runtimeStats
+	// latency records the handler latencies reported by LatencyStats, if enabled.
+	latency *LatencyTracker
+	// observers holds the observers added with OnDispatch and OnError, if any.
+	observers atomic.Pointer[observers]
+	// groups numbers the groups created from the bus.
+	groups atomic.Uint64
+
+	mu          sync.RWMutex
+	onRegister  []func(cmdType reflect.Type, op OpType, module Bus)
+	onUnhandled []func(cmdType reflect.Type, op OpType)
+	onFirstUse  []func(cmdType reflect.Type, buildDur time.Duration)
+	// order holds the middleware ordering constraints checked by Verify.
+	order []orderConstraint
+}
+
+// commandOptions holds the timeout, hooks and normalizers of a command type, so an execution
+// looks them up at once. It is never modified: a change stores a modified copy.
+type commandOptions struct {
+	// timeout is set with SetTimeout, or declared with a struct tag.
+	timeout     time.Duration
+	hooks       []commandHook
+	normalizers []func(Command)
+}
+
+// optionsFor returns the options of the command type.
+func (r *registry) optionsFor(t reflect.Type) *commandOptions {
+	if o, ok := r.options.Load(t); ok {
+		return o.(*commandOptions)
+	}
+	o, _ := r.options.LoadOrStore(t, &commandOptions{timeout: parseTimeoutTag(t)})
+	return o.(*commandOptions)
+}
+
+// updateOptions stores a copy of the options of the command type modified by fn.
+func (r *registry) updateOptions(t reflect.Type, fn func(o *commandOptions)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := *r.optionsFor(t)
+	fn(&o)
+	r.options.Store(t, &o)
+}
+
+// notifyRegister calls the registration callbacks.
+func (r *registry) notifyRegister(cmdType reflect.Type, op OpType, module Bus) {
+	r.mu.RLock()
+	callbacks := r.onRegister
+	r.mu.RUnlock()
+	for _, fn := range callbacks {
+		fn(cmdType, op, module)
+	}
+}
+
+// notifyUnhandled calls the callbacks for commands without a handler.
+func (r *registry) notifyUnhandled(cmdType reflect.Type, op OpType) {
+	r.mu.RLock()
+	callbacks := r.onUnhandled
+	r.mu.RUnlock()
+	for _, fn := range callbacks {
+		fn(cmdType, op)
+	}
+}
+
+// notifyFirstUse calls the first use callbacks, once per command and operation type of the set.
+func (r *registry) notifyFirstUse(set *handlerSet, key handlerKey, buildDur time.Duration) {
+	if _, loaded := set.used.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	r.mu.RLock()
+	callbacks := r.onFirstUse
+	r.mu.RUnlock()
+	for _, fn := range callbacks {
+		fn(key.t, buildDur)
+	}
+}
+
+// newRegistry returns a registry with an empty handler set.
+func newRegistry() *registry {
+	r := &registry{}
+	r.current.Store(newHandlerSet())
+	return r
+}
+
+// load returns the current handler set.
+func (r *registry) load() *handlerSet {
+	return r.current.Load()
+}
+
+// handlerSet is a set of handlers together with its resolution cache.
+// Handlers and cache are kept per operation type, so the same command type
+// can be handled differently as an action and as a query.
+type handlerSet struct {
+	entries [2]*sync.Map
+	cache   [2]*syncMap
+	// used holds the handler keys already resolved from the set.
+	used sync.Map
+}
+
+// newHandlerSet returns an empty handler set.
+func newHandlerSet() *handlerSet {
+	s := &handlerSet{}
+	for i := range s.entries {
+		s.entries[i] = &sync.Map{}
+		s.cache[i] = &syncMap{kv: make(map[reflect.Type]any)}
+	}
+	return s
+}
+
+// handlerKey identifies the handler of a command type for an operation type.
+type handlerKey struct {
+	op OpType
+	t  reflect.Type
+}
+
+// opIndex returns the index of the handler maps for the operation type.
+func opIndex(op OpType) int {
+	if op == ACTION {
+		return 0
+	}
+	return 1
+}
+
+// entriesFor returns the handlers of the operation type by command type.
+func (s *handlerSet) entriesFor(op OpType) *sync.Map {
+	return s.entries[opIndex(op)]
+}
+
+// lookup returns the handler of the command type for the operation type.
+// A query type with a Validate method is registered as an action, so a command type
+// missing from the handlers of the operation type is looked up in those of the other one.
+func (s *handlerSet) lookup(op OpType, t reflect.Type) (*handler, bool) {
+	if v, ok := s.entriesFor(op).Load(t); ok {
+		return v.(*handler), true
+	}
+	other := ACTION
+	if op == ACTION {
+		other = QUERY
+	}
+	if v, ok := s.entriesFor(other).Load(t); ok {
+		return v.(*handler), true
+	}
+	return nil, false
+}
+
+// cacheFor returns the resolution cache of the operation type.
+func (s *handlerSet) cacheFor(op OpType) *syncMap {
+	return s.cache[opIndex(op)]
+}
+
+// rangeAll calls fn for each registered handler, actions first.
+func (s *handlerSet) rangeAll(fn func(key handlerKey, h *handler) bool) {
+	for _, entries := range s.entries {
+		cont := true
+		entries.Range(func(k, v any) bool {
+			h := v.(*handler)
+			cont = fn(handlerKey{op: h.op, t: k.(reflect.Type)}, h)
+			return cont
+		})
+		if !cont {
+			return
+		}
+	}
+}
+
 // syncMap is a structure that holds a map of handlers.
 type syncMap struct {
 	kv map[reflect.Type]any
@@ -25,3 +214,10 @@ func (m *syncMap) store(key reflect.Type, value any) {
 	defer m.mu.Unlock()
 	m.kv[key] = value
 }
+
+// delete removes the value stored in the map.
+func (m *syncMap) delete(key reflect.Type) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.kv, key)
+}
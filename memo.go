@@ -0,0 +1,104 @@
+package dew
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Identifier is implemented by queries that can be memoized by the Memoize middleware.
+type Identifier interface {
+	// Identity returns a key identifying the query: queries of the same type
+	// with the same identity are expected to produce the same result.
+	Identity() string
+}
+
+// memoKey identifies a memoized query by type and identity.
+type memoKey struct {
+	t        reflect.Type
+	identity string
+}
+
+type memoEntry struct {
+	key    memoKey
+	result reflect.Value
+	// expires is the time the entry expires at, if not zero.
+	expires time.Time
+}
+
+// memoStore is a size-bounded store evicting the least recently used entry.
+// Entries stored with an expiry time are dropped once expired.
+type memoStore struct {
+	mu      sync.Mutex
+	size    int
+	entries map[memoKey]*list.Element
+	lru     *list.List
+}
+
+func (s *memoStore) load(key memoKey, now time.Time) (reflect.Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	entry := e.Value.(*memoEntry)
+	if !entry.expires.IsZero() && !now.Before(entry.expires) {
+		s.lru.Remove(e)
+		delete(s.entries, key)
+		return reflect.Value{}, false
+	}
+	s.lru.MoveToFront(e)
+	return entry.result, true
+}
+
+func (s *memoStore) store(key memoKey, result reflect.Value, expires time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		entry := e.Value.(*memoEntry)
+		entry.result, entry.expires = result, expires
+		s.lru.MoveToFront(e)
+		return
+	}
+	s.entries[key] = s.lru.PushFront(&memoEntry{key: key, result: result, expires: expires})
+	if s.size > 0 && s.lru.Len() > s.size {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoEntry).key)
+	}
+}
+
+// Memoize returns a query middleware that memoizes the results of Identifier queries.
+// Queries are keyed by type and Identity.
+// A query with the same type and key as a previously succeeded one is not executed
+// and receives a deep copy of the memoized query instead. Results are kept until evicted:
+// at most size results are kept, evicting the least recently used one; if size is zero
+// or negative, the number of results is unbounded.
+// Only use it for pure queries, whose result depends on their identity alone.
+// Actions are never memoized, so their side effects always happen.
+func Memoize(size int) func(next Middleware) Middleware {
+	s := &memoStore{size: size, entries: make(map[memoKey]*list.Element), lru: list.New()}
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			query, ok := ctx.Command().(Identifier)
+			if !ok || ctx.Op() != QUERY {
+				return next.Handle(ctx)
+			}
+			v := reflect.ValueOf(query).Elem()
+			key := memoKey{t: v.Type(), identity: query.Identity()}
+			if result, ok := s.load(key, time.Time{}); ok {
+				deepCopy(v, result)
+				return nil
+			}
+			if err := next.Handle(ctx); err != nil {
+				return err
+			}
+			result := reflect.New(v.Type()).Elem()
+			deepCopy(result, v)
+			s.store(key, result, time.Time{})
+			return nil
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package dew_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type computeScore struct {
+	UserID int
+	Result string
+}
+
+func (q computeScore) Identity() string { return strconv.Itoa(q.UserID) }
+
+func TestMemoize(t *testing.T) {
+	calls := map[int]int{}
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.Memoize(2))
+	mux.Register(dew.HandlerFunc[computeScore](func(ctx context.Context, query *computeScore) error {
+		calls[query.UserID]++
+		query.Result = fmt.Sprintf("score-%d-%d", query.UserID, calls[query.UserID])
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	run := func(id int) string {
+		t.Helper()
+		return testRunQuery(t, ctx, &computeScore{UserID: id}).Result
+	}
+
+	if got := run(1); got != "score-1-1" {
+		t.Fatalf("unexpected result: %s", got)
+	}
+	if got := run(1); got != "score-1-1" || calls[1] != 1 {
+		t.Fatalf("expected the memoized result, got %s after %d calls", got, calls[1])
+	}
+
+	// 1 is used more recently than 2, so 3 evicts 2.
+	run(2)
+	run(1)
+	run(3)
+	if got := run(1); got != "score-1-1" {
+		t.Fatalf("expected 1 to be kept, got: %s", got)
+	}
+	if got := run(2); got != "score-2-2" {
+		t.Fatalf("expected 2 to be evicted, got: %s", got)
+	}
+}
+
+func TestMemoize_NotIdentifier(t *testing.T) {
+	calls := 0
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.Memoize(0))
+	mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+		calls++
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+type scoreReport struct {
+	UserID int
+	Result int
+}
+
+func (q scoreReport) Identity() string { return fmt.Sprint(q.UserID) }
+
+func (q scoreReport) CacheKey() string { return "reports" }
+
+func TestMemoize_Identity(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.Memoize(0))
+	mux.Register(dew.HandlerFunc[scoreReport](func(ctx context.Context, query *scoreReport) error {
+		query.Result = query.UserID
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// the key is the Identity, even if the query has a CacheKey
+	testRunQuery(t, ctx, &scoreReport{UserID: 1})
+	if result := testRunQuery(t, ctx, &scoreReport{UserID: 2}); result.Result != 2 {
+		t.Fatalf("unexpected result: %d", result.Result)
+	}
+}
+
+type awardBadge struct {
+	UserID int
+}
+
+func (awardBadge) Validate(context.Context) error { return nil }
+
+func (c awardBadge) Identity() string { return fmt.Sprint(c.UserID) }
+
+func TestMemoize_Action(t *testing.T) {
+	awards := 0
+	mux := dew.New()
+	mux.Use(dew.ALL, dew.Memoize(0))
+	mux.Register(dew.HandlerFunc[awardBadge](func(ctx context.Context, action *awardBadge) error {
+		awards++
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// actions are executed every time, even with an identity
+	testRunDispatch(t, ctx, dew.NewAction(&awardBadge{UserID: 1}))
+	testRunDispatch(t, ctx, dew.NewAction(&awardBadge{UserID: 1}))
+	if awards != 2 {
+		t.Fatalf("unexpected number of awards: %d", awards)
+	}
+}
+
+type listBadges struct {
+	UserID int
+	Result []string
+}
+
+func (q listBadges) Identity() string { return fmt.Sprint(q.UserID) }
+
+func TestMemoize_DeepCopy(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.Memoize(0))
+	mux.Register(dew.HandlerFunc[listBadges](func(ctx context.Context, query *listBadges) error {
+		query.Result = []string{"early", "bird"}
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	first := testRunQuery(t, ctx, &listBadges{UserID: 1})
+	first.Result[0] = "changed"
+	second := testRunQuery(t, ctx, &listBadges{UserID: 1})
+	second.Result[1] = "changed"
+	if third := testRunQuery(t, ctx, &listBadges{UserID: 1}); third.Result[0] != "early" || third.Result[1] != "bird" {
+		t.Fatalf("the memoized query shares its result: %v", third.Result)
+	}
+}
@@ -1,5 +1,13 @@
 package dew
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrResultTooLarge is returned by the LimitResultSize middleware when a query result exceeds the limit.
+var ErrResultTooLarge = errors.New("result too large")
+
 // Middleware is an interface for handling middleware.
 type Middleware interface {
 	// Handle executes the middleware.
@@ -13,3 +21,70 @@ type MiddlewareFunc func(ctx Context) error
 func (h MiddlewareFunc) Handle(ctx Context) error {
 	return h(ctx)
 }
+
+// QueryBatchFunc processes the whole batch of queries passed to QueryAsync before they run.
+// It returns the queries to execute, e.g. reordered, deduplicated, or capped.
+// The returned queries must be taken from the given batch.
+type QueryBatchFunc func(ctx Context, queries []CommandHandler[Command]) ([]CommandHandler[Command], error)
+
+// ShortCircuitedBy returns the name of the middleware that returned an error
+// without calling next during the last command execution on the context.
+// It returns an empty string if the chain was not short-circuited.
+func ShortCircuitedBy(ctx Context) string {
+	bctx, ok := ctx.(*BusContext)
+	if !ok {
+		return ""
+	}
+	return bctx.shortCircuitedBy
+}
+
+// TransformResult returns a middleware that calls fn on commands of type T
+// after the handler has succeeded, e.g. to post-process their results.
+// Commands of other types are passed through unchanged.
+func TransformResult[T Command](fn func(command *T)) func(next Middleware) Middleware {
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			if err := next.Handle(ctx); err != nil {
+				return err
+			}
+			if cmd, ok := ctx.Command().(*T); ok {
+				fn(cmd)
+			}
+			return nil
+		})
+	}
+}
+
+// Around returns a middleware that calls fn for commands of type T with the typed command
+// and a next callback executing the rest of the chain, so fn can read or modify the command
+// before calling next and read its result after. Commands of other types are passed through.
+func Around[T Command](fn func(ctx Context, cmd *T, next func() error) error) func(next Middleware) Middleware {
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			cmd, ok := ctx.Command().(*T)
+			if !ok {
+				return next.Handle(ctx)
+			}
+			return fn(ctx, cmd, func() error {
+				return next.Handle(ctx)
+			})
+		})
+	}
+}
+
+// LimitResultSize returns a query middleware that rejects results larger than max
+// with ErrResultTooLarge, e.g. an unbounded list. The size of the command is computed
+// with sizeFn after the handler has succeeded.
+func LimitResultSize(max int, sizeFn func(Command) int) func(next Middleware) Middleware {
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			if err := next.Handle(ctx); err != nil {
+				return err
+			}
+			if size := sizeFn(ctx.Command()); size > max {
+				return fmt.Errorf("%w: %T has size %d, max %d", ErrResultTooLarge, ctx.Command(), size, max)
+			}
+			return nil
+		})
+	}
+}
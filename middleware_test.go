@@ -0,0 +1,382 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+var errDenied = errors.New("denied")
+
+func denyAll(next dew.Middleware) dew.Middleware {
+	return dew.MiddlewareFunc(func(ctx dew.Context) error {
+		return errDenied
+	})
+}
+
+func passThrough(next dew.Middleware) dew.Middleware {
+	return dew.MiddlewareFunc(func(ctx dew.Context) error {
+		return next.Handle(ctx)
+	})
+}
+
+func TestShortCircuitedBy(t *testing.T) {
+	t.Run("RecordsDenyingMiddleware", func(t *testing.T) {
+		var name string
+		mux := dew.New()
+		mux.UseDispatch(func(next dew.Middleware) dew.Middleware {
+			return dew.MiddlewareFunc(func(ctx dew.Context) error {
+				err := next.Handle(ctx)
+				name = dew.ShortCircuitedBy(ctx)
+				return err
+			})
+		})
+		mux.Use(dew.ALL, passThrough)
+		mux.Use(dew.ACTION, denyAll)
+		mux.Register(new(userHandler))
+		ctx := dew.NewContext(context.Background(), mux)
+
+		_, err := dew.Dispatch(ctx, &createUser{Name: "john"})
+		if !errors.Is(err, errDenied) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasSuffix(name, ".denyAll") {
+			t.Fatalf("unexpected middleware name: %q", name)
+		}
+	})
+
+	t.Run("HandlerErrorIsNotShortCircuit", func(t *testing.T) {
+		name := "unset"
+		mux := dew.New()
+		mux.UseDispatch(func(next dew.Middleware) dew.Middleware {
+			return dew.MiddlewareFunc(func(ctx dew.Context) error {
+				err := next.Handle(ctx)
+				name = dew.ShortCircuitedBy(ctx)
+				return err
+			})
+		})
+		mux.Use(dew.ALL, passThrough)
+		mux.Register(new(userHandler))
+		ctx := dew.NewContext(context.Background(), mux)
+
+		_, err := dew.Dispatch(ctx, &createUser{Name: ""})
+		if !errors.Is(err, errNameRequired) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if name != "" {
+			t.Fatalf("expected no short-circuiting middleware, got: %q", name)
+		}
+	})
+}
+
+func TestMux_UseHandlerWrapper(t *testing.T) {
+	var calls []string
+	record := func(name string) func(next dew.Middleware) dew.Middleware {
+		return func(next dew.Middleware) dew.Middleware {
+			return dew.MiddlewareFunc(func(ctx dew.Context) error {
+				calls = append(calls, name+":before")
+				err := next.Handle(ctx)
+				calls = append(calls, name+":after")
+				return err
+			})
+		}
+	}
+
+	mux := dew.New()
+	mux.UseHandlerWrapper(dew.ACTION, record("wrapper"))
+	mux.Use(dew.ALL, record("outer"))
+	mux.Group(func(mux dew.Bus) {
+		mux.Use(dew.ALL, record("inner"))
+		mux.Register(dew.HandlerFunc[createUser](
+			func(ctx context.Context, command *createUser) error {
+				calls = append(calls, "handler")
+				return nil
+			},
+		))
+		mux.Register(dew.HandlerFunc[findUser](
+			func(ctx context.Context, query *findUser) error {
+				calls = append(calls, "handler")
+				return nil
+			},
+		))
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	expected := []string{
+		"outer:before", "inner:before",
+		"wrapper:before", "handler", "wrapper:after",
+		"inner:after", "outer:after",
+	}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	// the wrapper only applies to actions
+	calls = nil
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	expected = []string{
+		"outer:before", "inner:before", "handler", "inner:after", "outer:after",
+	}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestMux_UseFor(t *testing.T) {
+	var calls []string
+	record := func(name string) func(next dew.Middleware) dew.Middleware {
+		return func(next dew.Middleware) dew.Middleware {
+			return dew.MiddlewareFunc(func(ctx dew.Context) error {
+				calls = append(calls, name+":before")
+				err := next.Handle(ctx)
+				calls = append(calls, name+":after")
+				return err
+			})
+		}
+	}
+
+	mux := dew.New()
+	mux.UseHandlerWrapper(dew.QUERY, record("wrapper"))
+	mux.Use(dew.QUERY, record("outer"))
+	mux.UseFor(findUser{}, record("cache"))
+	mux.Register(new(userHandler))
+	mux.Group(func(mux dew.Bus) {
+		mux.UseFor((*findPost)(nil), denyAll)
+		mux.Register(new(postHandler))
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	expected := []string{
+		"outer:before", "cache:before",
+		"wrapper:before", "wrapper:after",
+		"cache:after", "outer:after",
+	}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	// the middlewares only apply to their command type
+	calls = nil
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	if len(calls) != 0 {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	// the middlewares of the group apply to the commands handled by the group
+	calls = nil
+	if _, err := dew.Query(ctx, &findPost{ID: 1}); !errors.Is(err, errDenied) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(calls, ",") != "outer:before,outer:after" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	// middlewares added later are applied to the next executions
+	calls = nil
+	mux.UseFor(findUser{}, record("audit"))
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	expected = []string{
+		"outer:before", "cache:before", "audit:before",
+		"wrapper:before", "wrapper:after",
+		"audit:after", "cache:after", "outer:after",
+	}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestContext_Op(t *testing.T) {
+	var logs []string
+	var dispatchOp dew.OpType
+	mux := dew.New()
+	mux.UseDispatch(func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			dispatchOp = ctx.Op()
+			return next.Handle(ctx)
+		})
+	})
+	mux.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			logs = append(logs, fmt.Sprintf("%v %s", ctx.Op(), reflect.TypeOf(ctx.Command()).Elem().Name()))
+			return next.Handle(ctx)
+		})
+	})
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	if got := strings.Join(logs, ","); got != "ACTION createUser,QUERY findUser" {
+		t.Fatalf("unexpected logs: %s", got)
+	}
+	// the operation type is only set for the execution of a command
+	if dispatchOp != 0 {
+		t.Fatalf("unexpected operation type: %v", dispatchOp)
+	}
+	if dew.ALL.String() != "ALL" {
+		t.Fatalf("unexpected name: %v", dew.ALL)
+	}
+}
+
+func TestMux_MaxMiddlewareDepth(t *testing.T) {
+	mux := dew.New(dew.WithMaxMiddlewareDepth(2))
+	mux.Use(dew.ALL, passThrough)
+	mux.Use(dew.QUERY, passThrough)
+	mux.Register(new(userHandler))
+
+	if d := mux.MiddlewareDepth(dew.ACTION); d != 1 {
+		t.Fatalf("unexpected action depth: %d", d)
+	}
+	if d := mux.MiddlewareDepth(dew.QUERY); d != 2 {
+		t.Fatalf("unexpected query depth: %d", d)
+	}
+
+	// actions still have room
+	mux.Group(func(mux dew.Bus) {
+		mux.Use(dew.ACTION, passThrough)
+		mux.Register(new(postHandler))
+	})
+	if err := mux.Verify(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// exceeding the depth is reported by Verify instead of Use
+	mux.Group(func(mux dew.Bus) {
+		mux.Use(dew.ALL, passThrough)
+		mux.Register(new(userHandler))
+	})
+	err := mux.Verify()
+	if !errors.Is(err, dew.ErrMiddlewareDepthExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "3 > 2 in the QUERY chain") || strings.Contains(err.Error(), "ACTION") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMux_MaxMiddlewareDepth_UseFor(t *testing.T) {
+	newBus := func() dew.Bus {
+		mux := dew.New(dew.WithMaxMiddlewareDepth(2))
+		mux.Use(dew.QUERY, passThrough)
+		mux.UseFor(findUser{}, passThrough)
+		// other command types still have room
+		mux.UseFor(findPost{}, passThrough)
+		mux.UseHandlerWrapper(dew.ACTION, passThrough)
+		return mux
+	}
+	if err := newBus().Verify(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		use  func(mux dew.Bus)
+		want string
+	}{
+		"UseFor": {
+			use:  func(mux dew.Bus) { mux.UseFor(findUser{}, passThrough) },
+			want: "3 > 2 in the dew_test.findUser chain",
+		},
+		"Use": {
+			use:  func(mux dew.Bus) { mux.Use(dew.QUERY, passThrough) },
+			want: "3 > 2 in the dew_test.findPost chain",
+		},
+		"UseHandlerWrapper": {
+			use:  func(mux dew.Bus) { mux.UseHandlerWrapper(dew.ALL, passThrough) },
+			want: "3 > 2 in the dew_test.findUser chain",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mux := newBus()
+			tc.use(mux)
+			err := mux.Verify()
+			if !errors.Is(err, dew.ErrMiddlewareDepthExceeded) || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransformResult(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.TransformResult(func(query *findUser) {
+		query.Result = strings.ToUpper(query.Result)
+	}))
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "JOHN" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if result := testRunQuery(t, ctx, &findPost{ID: 1}); result.Result != "hello" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if _, err := dew.Query(ctx, &findUser{ID: 2}); !errors.Is(err, errUserNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAround(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.Around(func(ctx dew.Context, query *findUser, next func() error) error {
+		query.ID--
+		if err := next(); err != nil {
+			return err
+		}
+		query.Result = strings.ToUpper(query.Result)
+		return nil
+	}))
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if result := testRunQuery(t, ctx, &findUser{ID: 2}); result.Result != "JOHN" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if result := testRunQuery(t, ctx, &findPost{ID: 1}); result.Result != "hello" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if _, err := dew.Query(ctx, &findUser{ID: 3}); !errors.Is(err, errUserNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type searchUsers struct {
+	Limit  int
+	Result []string
+}
+
+func TestLimitResultSize(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.QUERY, dew.LimitResultSize(2, func(cmd dew.Command) int {
+		if query, ok := cmd.(*searchUsers); ok {
+			return len(query.Result)
+		}
+		return 0
+	}))
+	mux.Register(dew.HandlerFunc[searchUsers](func(ctx context.Context, query *searchUsers) error {
+		for i := 0; i < query.Limit; i++ {
+			query.Result = append(query.Result, "john")
+		}
+		return nil
+	}))
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if result := testRunQuery(t, ctx, &searchUsers{Limit: 2}); len(result.Result) != 2 {
+		t.Fatalf("unexpected result: %v", result.Result)
+	}
+	if _, err := dew.Query(ctx, &searchUsers{Limit: 3}); !errors.Is(err, dew.ErrResultTooLarge) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
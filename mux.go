@@ -2,8 +2,15 @@ package dew
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
@@ -12,22 +19,91 @@ var (
 
 // mux is the main struct where all handlers and middlewares are registered.
 type mux struct {
-	parent      *mux
+	parent *mux
+	// id orders the groups of a bus by creation.
+	id uint64
+	// groupCount counts the groups created from the mux, including nested groups.
+	// The mux does not reference its groups, so they are released with their last reference.
+	groupCount  atomic.Int64
 	inline      bool
 	lock        sync.RWMutex
-	entries     *sync.Map
+	handlers    *registry
 	handler     [ALL]Middleware
 	middlewares [mAll][]middleware
-	mHandlers   [mAll]func(ctx Context, fn mHandlerFunc) error
-	cache       *syncMap
+	wrappers    []middleware
+	// typed holds the middlewares added with UseFor by command type.
+	typed map[reflect.Type][]middleware
+	// typedHandler caches the route handlers of the command types with middlewares.
+	typedHandler map[reflect.Type]Middleware
+	queryBatch   []QueryBatchFunc
+	maxDepth     int
+	aggregate    func(errs []error) error
+	failFast     bool
+	poison       bool
+	mHandlers    [mAll]func(ctx Context, fn mHandlerFunc) error
 
 	// context pool
 	pool *sync.Pool
 }
 
 // New creates an instance of the Command Bus.
-func New() Bus {
-	return newMux()
+func New(opts ...Option) Bus {
+	mx := newMux()
+	for _, opt := range opts {
+		opt(mx)
+	}
+	return mx
+}
+
+// Option configures the bus created by New.
+type Option func(mx *mux)
+
+// WithMaxMiddlewareDepth limits the number of command middlewares and handler wrappers executed
+// for a command, including the middlewares added with UseFor for its type. Verify reports the
+// chains exceeding the limit with an error wrapping ErrMiddlewareDepthExceeded.
+func WithMaxMiddlewareDepth(n int) Option {
+	return func(mx *mux) {
+		mx.maxDepth = n
+	}
+}
+
+// WithErrorAggregator sets the function combining the errors of asynchronous executions
+// such as QueryAsync. It is only called when at least one error occurred.
+// By default, errors are combined with errors.Join.
+func WithErrorAggregator(fn func(errs []error) error) Option {
+	return func(mx *mux) {
+		mx.aggregate = fn
+	}
+}
+
+// WithOpNameKey makes the bus set OpNameKey to the type name of the command being executed,
+// for logging middlewares reading it from the context.Context. It is disabled by default, as it
+// costs an allocation per command; the middlewares of the bus can use Context.Command instead.
+func WithOpNameKey() Option {
+	return func(mx *mux) {
+		mx.handlers.opName = true
+	}
+}
+
+// WithFailFast makes asynchronous executions such as QueryAsync and DispatchAsync stop at the
+// first error: the context of the other commands is cancelled, so handlers observing it
+// return early, the commands not started yet are skipped, and only the first error is returned.
+// By default, all commands run to completion and all their errors are returned.
+func WithFailFast() Option {
+	return func(mx *mux) {
+		mx.failFast = true
+	}
+}
+
+// aggregateErrors combines the errors with the configured aggregator.
+func (mx *mux) aggregateErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	if mx.aggregate != nil {
+		return mx.aggregate(errs)
+	}
+	return errors.Join(errs...)
 }
 
 // OpType represents the type of operation.
@@ -42,6 +118,19 @@ const (
 
 const ALL OpType = ACTION | QUERY
 
+// String returns "ACTION", "QUERY", or "ALL".
+func (op OpType) String() string {
+	switch op {
+	case ACTION:
+		return "ACTION"
+	case QUERY:
+		return "QUERY"
+	case ALL:
+		return "ALL"
+	}
+	return fmt.Sprintf("OpType(%d)", uint8(op))
+}
+
 type mHandlerFunc func(ctx Context) error
 
 type middlewareType int
@@ -55,22 +144,110 @@ const (
 
 // newMux returns a newly initialized Mux object that implements the dispatcher interface.
 func newMux() *mux {
-	mux := &mux{entries: &sync.Map{}, pool: &sync.Pool{}}
+	mux := &mux{handlers: newRegistry(), pool: &sync.Pool{}}
 	mux.pool.New = func() interface{} {
+		mux.handlers.stats.poolMisses.Add(1)
 		return &BusContext{}
 	}
-	mux.cache = &syncMap{kv: make(map[reflect.Type]any)}
 	return mux
 }
 
+// newContext returns a reset context from the pool bound to the given context.
+func (mx *mux) newContext(ctx context.Context) *BusContext {
+	rctx := mx.acquire()
+	rctx.Reset()
+	rctx.ctx, rctx.request = newRequestContext(ctx, mx)
+	rctx.counter, _ = ctx.Value(commandCountsKey{}).(*commandCounter)
+	rctx.breadcrumbs, _ = ctx.Value(breadcrumbsKey{}).(*breadcrumbTrail)
+	rctx.overrides, _ = ctx.Value(overridesKey{}).(map[reflect.Type]any)
+	return rctx
+}
+
 // Use appends the middlewares to the mux middleware chain.
 // The middleware chain will be executed in the order they were added.
 func (mx *mux) Use(op OpType, middlewares ...func(next Middleware) Middleware) {
+	mx.lock.Lock()
+	defer mx.lock.Unlock()
 	for _, mw := range middlewares {
 		mx.middlewares[mCmd] = append(mx.middlewares[mCmd], middleware{op: op, fn: mw})
 	}
 }
 
+// UseFor appends the middlewares to the chain of the command type of the sample,
+// e.g. FindUser{} or (*FindUser)(nil). They are executed in the order they were added,
+// inside the middlewares added with Use and outside the handler wrappers.
+// Like Use, the middlewares are inherited by the groups created afterwards.
+func (mx *mux) UseFor(command any, middlewares ...func(next Middleware) Middleware) {
+	t := commandType(command)
+	if t == nil {
+		panic("UseFor requires a command sample")
+	}
+	mx.lock.Lock()
+	defer mx.lock.Unlock()
+	if mx.typed == nil {
+		mx.typed = make(map[reflect.Type][]middleware)
+	}
+	for _, mw := range middlewares {
+		mx.typed[t] = append(mx.typed[t], middleware{op: ALL, fn: mw})
+	}
+	delete(mx.typedHandler, t)
+}
+
+// MiddlewareDepth returns the number of command middlewares and handler wrappers
+// executed for the given operation type, excluding the middlewares added with UseFor.
+func (mx *mux) MiddlewareDepth(op OpType) int {
+	mx.lock.RLock()
+	defer mx.lock.RUnlock()
+	return mx.depth(op)
+}
+
+// depth returns the middleware depth for op. It must be called with the lock held.
+func (mx *mux) depth(op OpType) int {
+	return len(filterMiddleware(op, mx.middlewares[mCmd])) + len(filterMiddleware(op, mx.wrappers))
+}
+
+// checkDepth returns an error wrapping ErrMiddlewareDepthExceeded for each chain of the mux
+// exceeding the maximum depth. The chain of a command type with middlewares added with UseFor
+// is only reported if the chain of its operation type does not exceed the depth already.
+// It must be called with the lock held.
+func (mx *mux) checkDepth() []error {
+	if mx.maxDepth <= 0 {
+		return nil
+	}
+	var errs []error
+	for _, op := range []OpType{ACTION, QUERY} {
+		if d := mx.depth(op); d > mx.maxDepth {
+			errs = append(errs, fmt.Errorf("%w: %d > %d in the %v chain", ErrMiddlewareDepthExceeded, d, mx.maxDepth, op))
+		}
+	}
+	var typed []error
+	for t, mws := range mx.typed {
+		base := mx.depth(opTypeOf(t))
+		if d := base + len(mws); base <= mx.maxDepth && d > mx.maxDepth {
+			typed = append(typed, fmt.Errorf("%w: %d > %d in the %v chain", ErrMiddlewareDepthExceeded, d, mx.maxDepth, t))
+		}
+	}
+	sort.Slice(typed, func(i, j int) bool { return typed[i].Error() < typed[j].Error() })
+	return append(errs, typed...)
+}
+
+// UseHandlerWrapper appends the wrappers to the handler wrapper chain.
+// Wrappers are executed in the order they were added, inside all other middlewares.
+func (mx *mux) UseHandlerWrapper(op OpType, wrappers ...func(next Middleware) Middleware) {
+	mx.lock.Lock()
+	defer mx.lock.Unlock()
+	for _, w := range wrappers {
+		mx.wrappers = append(mx.wrappers, middleware{op: op, fn: w})
+	}
+}
+
+// UseQueryBatch appends the functions to the query batch chain of QueryAsync.
+func (mx *mux) UseQueryBatch(fns ...QueryBatchFunc) {
+	mx.lock.Lock()
+	defer mx.lock.Unlock()
+	mx.queryBatch = append(mx.queryBatch, fns...)
+}
+
 // UseDispatch appends the middlewares to the dispatch middleware chain.
 func (mx *mux) UseDispatch(middlewares ...func(next Middleware) Middleware) {
 	mx.addMiddleware(mDispatch, middlewares)
@@ -82,6 +259,8 @@ func (mx *mux) UseQuery(middlewares ...func(next Middleware) Middleware) {
 }
 
 func (mx *mux) addMiddleware(m middlewareType, mws []func(next Middleware) Middleware) {
+	mx.lock.Lock()
+	defer mx.lock.Unlock()
 	for _, mw := range mws {
 		mx.middlewares[m] = append(mx.middlewares[m], middleware{fn: mw})
 	}
@@ -96,8 +275,92 @@ func (mx *mux) Group(fn func(mx Bus)) Bus {
 	return child
 }
 
+// CleanGroup creates a new mux sharing the registered handlers but without
+// inheriting the parent middlewares.
+func (mx *mux) CleanGroup(fn func(mx Bus)) Bus {
+	child := &mux{
+		parent:    mx,
+		inline:    true,
+		maxDepth:  mx.maxDepth,
+		aggregate: mx.aggregate,
+		failFast:  mx.failFast,
+		poison:    mx.poison,
+		handlers:  mx.handlers,
+		pool:      mx.pool,
+	}
+	child.initHandlers()
+	mx.addGroup(child)
+	if fn != nil {
+		fn(child)
+	}
+	return child
+}
+
+// initHandlers sets up the dispatch and query handlers of a new group,
+// so it can be the target of DispatchTo and QueryTo before any handler is registered to it.
+func (mx *mux) initHandlers() {
+	for _, m := range []middlewareType{mQuery, mDispatch} {
+		mx.mHandlers[m] = mx.newMHandler(m)
+	}
+}
+
+// addGroup numbers the group created from the mux and counts it for the mux and its ancestors.
+func (mx *mux) addGroup(group *mux) {
+	group.id = mx.handlers.groups.Add(1)
+	for p := mx; p != nil; p = p.parent {
+		p.groupCount.Add(1)
+	}
+}
+
+// groups returns the groups of the mux holding registered handlers, with their parents
+// up to the mux, in creation order. The other groups are not referenced by the bus.
+func (mx *mux) groups() []*mux {
+	seen := make(map[*mux]bool)
+	var groups []*mux
+	for _, entries := range mx.handlers.load().entries {
+		entries.Range(func(_, v any) bool {
+			for _, h := range v.(*handler).all {
+				var path []*mux
+				for g := h.mux; g != nil; g = g.parent {
+					if g == mx || seen[g] {
+						for _, g := range path {
+							seen[g] = true
+						}
+						groups = append(groups, path...)
+						break
+					}
+					path = append(path, g)
+				}
+			}
+			return true
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].id < groups[j].id })
+	return groups
+}
+
+// GroupCount returns the number of groups created from the mux, including nested groups.
+// Released groups are still counted, so a count growing with the traffic reveals groups
+// created per request.
+func (mx *mux) GroupCount() int {
+	return int(mx.groupCount.Load())
+}
+
+// Warmup builds the middleware chains of the mux and of its groups holding registered
+// handlers, so the first dispatch does not pay for building them.
+func (mx *mux) Warmup() {
+	for _, g := range append([]*mux{mx}, mx.groups()...) {
+		g.updateRouteHandler(ACTION)
+		g.updateRouteHandler(QUERY)
+		g.updateHandler(mDispatch)
+		g.updateHandler(mQuery)
+	}
+}
+
 // with creates a new mux with the given middlewares.
 func (mx *mux) child() Bus {
+	mx.lock.Lock()
+	defer mx.lock.Unlock()
 
 	// copy the parent middlewares
 	var mws [mAll][]middleware
@@ -106,24 +369,80 @@ func (mx *mux) child() Bus {
 		copy(mws[i], mx.middlewares[i])
 	}
 
-	return &mux{
+	wrappers := make([]middleware, len(mx.wrappers))
+	copy(wrappers, mx.wrappers)
+
+	var typed map[reflect.Type][]middleware
+	if len(mx.typed) > 0 {
+		typed = make(map[reflect.Type][]middleware, len(mx.typed))
+		for t, mws := range mx.typed {
+			typed[t] = append([]middleware(nil), mws...)
+		}
+	}
+
+	child := &mux{
 		parent:      mx,
 		inline:      true,
 		middlewares: mws,
-		entries:     mx.entries,
-		cache:       mx.cache,
+		wrappers:    wrappers,
+		typed:       typed,
+		maxDepth:    mx.maxDepth,
+		aggregate:   mx.aggregate,
+		failFast:    mx.failFast,
+		poison:      mx.poison,
+		handlers:    mx.handlers,
+		pool:        mx.pool,
 	}
+	child.initHandlers()
+	mx.addGroup(child)
+	return child
 }
 
 // dispatch dispatches the command to the appropriate Executor.
 func (mx *mux) dispatch(op OpType, ctx Context, h internalHandler) error {
-	hh := mx.handlerFor(op)
-	if hh == nil {
-		mx.updateRouteHandler(op)
-		hh = mx.handlerFor(op)
+	typ := reflect.TypeOf(h.Command()).Elem()
+	hh := mx.handlerForType(op, typ)
+	bctx := ctx.(*BusContext)
+	parent := bctx.Context()
+	cctx, err := mx.handlers.enter(parent, typ)
+	if err != nil {
+		return err
+	}
+	bctx.handler = h
+	bctx.op = op
+	bctx.reached = 0
+	bctx.shortCircuitedBy = ""
+	if bctx.counter != nil {
+		bctx.counter.add(typ)
+	}
+	if bctx.breadcrumbs != nil {
+		bctx.breadcrumbs.add(typ.Name())
 	}
-	ctx.(*BusContext).handler = h
-	return hh.Handle(ctx)
+	opts := mx.handlers.optionsFor(typ)
+	if op == QUERY {
+		opts.normalize(h.Command())
+	}
+	defer func() { bctx.ctx = parent }()
+	bctx.ctx = cctx
+	if mx.handlers.opName {
+		bctx.ctx = context.WithValue(cctx, OpNameKey, typ.Name())
+	}
+	if d := opts.timeout; d > 0 {
+		var cancel context.CancelFunc
+		bctx.ctx, cancel = context.WithTimeout(bctx.ctx, d)
+		defer cancel()
+	}
+	o := mx.handlers.observers.Load()
+	var start time.Time
+	if o != nil {
+		start = time.Now()
+	}
+	err = hh.Handle(ctx)
+	opts.runHooks(ctx, h.Command(), err)
+	if o != nil {
+		o.observe(h.Command(), op, start, err)
+	}
+	return err
 }
 
 func (mx *mux) handlerFor(op OpType) Middleware {
@@ -132,8 +451,41 @@ func (mx *mux) handlerFor(op OpType) Middleware {
 	return mx.handler[op]
 }
 
+// handlerForType returns the route handler of the command type,
+// including the middlewares added with UseFor.
+func (mx *mux) handlerForType(op OpType, t reflect.Type) Middleware {
+	mx.lock.RLock()
+	hh, typed := mx.handler[op], len(mx.typed[t]) > 0
+	if typed {
+		hh = mx.typedHandler[t]
+	}
+	mx.lock.RUnlock()
+	if hh != nil {
+		return hh
+	}
+	if !typed {
+		mx.updateRouteHandler(op)
+		return mx.handlerFor(op)
+	}
+
+	mx.lock.Lock()
+	defer mx.lock.Unlock()
+	if hh = mx.typedHandler[t]; hh == nil {
+		mws := append(filterMiddleware(op, mx.middlewares[mCmd]), mx.typed[t]...)
+		hh = chain(op, mws, mx.routeHandler(op))
+		if mx.typedHandler == nil {
+			mx.typedHandler = make(map[reflect.Type]Middleware)
+		}
+		mx.typedHandler[t] = hh
+	}
+	return hh
+}
+
 func (mx *mux) newDispatchHandler(m middlewareType, fn func(ctx Context) error) Middleware {
-	return exec(mx.middlewares[m], MiddlewareFunc(
+	mx.lock.RLock()
+	mws := mx.middlewares[m]
+	mx.lock.RUnlock()
+	return exec(mws, MiddlewareFunc(
 		func(ctx Context) error {
 			return fn(ctx)
 		}))
@@ -142,7 +494,13 @@ func (mx *mux) newDispatchHandler(m middlewareType, fn func(ctx Context) error)
 func (mx *mux) updateRouteHandler(op OpType) {
 	mx.lock.Lock()
 	defer mx.lock.Unlock()
-	mx.handler[op] = chain(op, mx.middlewares[mCmd], MiddlewareFunc(
+	mx.handler[op] = chain(op, mx.middlewares[mCmd], mx.routeHandler(op))
+}
+
+// routeHandler returns the handler of the dispatched command wrapped with the handler wrappers.
+// It must be called with the lock held.
+func (mx *mux) routeHandler(op OpType) Middleware {
+	return wrap(op, mx.wrappers, MiddlewareFunc(
 		func(ctx Context) error {
 			return ctx.(*BusContext).handler.Handle(ctx)
 		}))
@@ -151,7 +509,11 @@ func (mx *mux) updateRouteHandler(op OpType) {
 func (mx *mux) updateHandler(m middlewareType) {
 	mx.lock.Lock()
 	defer mx.lock.Unlock()
-	mx.mHandlers[m] = func(ctx Context, fn mHandlerFunc) error {
+	mx.mHandlers[m] = mx.newMHandler(m)
+}
+
+func (mx *mux) newMHandler(m middlewareType) func(ctx Context, fn mHandlerFunc) error {
+	return func(ctx Context, fn mHandlerFunc) error {
 		return mx.newDispatchHandler(m, func(ctx Context) error {
 			return fn(ctx)
 		}).Handle(ctx)
@@ -159,7 +521,124 @@ func (mx *mux) updateHandler(m middlewareType) {
 }
 
 // Register adds the handler to the mux for the given command type.
+// It panics with ErrDuplicateHandler if several methods of the handler handle the same command type.
+// With WithStrictRegistration, it panics if a command type of the handler is already handled.
 func (mx *mux) Register(handler interface{}) {
+	methods := scanHandler(handler)
+	if err := checkMethods(methods); err != nil {
+		panic(err)
+	}
+	if err := mx.handlers.checkStrict(methods); err != nil {
+		panic(err)
+	}
+	for _, m := range methods {
+		mx.addHandler(m)
+	}
+	mx.setupHandler()
+}
+
+// RegisterMany registers each handler and returns the aggregated errors.
+// A handler that handles an already registered command type, or whose registration
+// panics, is skipped and reported without aborting the other registrations.
+func (mx *mux) RegisterMany(handlers ...any) error {
+	var errs []error
+	for _, h := range handlers {
+		if err := mx.tryRegister(h); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RegisterChecked registers the handler like Register, but returns an error instead of
+// registering nothing if the handler has no handler method. The error lists the methods
+// that were skipped and why, to catch wiring mistakes such as a command passed by value.
+// It also returns an error wrapping ErrDuplicateHandler, naming both handlers, if the handler
+// handles an already registered command type; nothing is registered in that case.
+func (mx *mux) RegisterChecked(handler any) error {
+	if handler == nil {
+		return fmt.Errorf("%w: nil handler", ErrNoHandlerMethods)
+	}
+	methods := scanHandler(handler)
+	if err := checkMethods(methods); err != nil {
+		return err
+	}
+	if err := mx.checkDuplicates(methods); err != nil {
+		return err
+	}
+	if err := mx.handlers.checkStrict(methods); err != nil {
+		return err
+	}
+	if len(methods) == 0 {
+		if skipped := skippedMethods(handler); len(skipped) > 0 {
+			return fmt.Errorf("%w on %T, skipped %s", ErrNoHandlerMethods, handler, strings.Join(skipped, "; "))
+		}
+		return fmt.Errorf("%w on %T", ErrNoHandlerMethods, handler)
+	}
+	mx.Register(handler)
+	return nil
+}
+
+// tryRegister registers the handler unless it conflicts with a registered one.
+func (mx *mux) tryRegister(h any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("register %T: %v", h, r)
+		}
+	}()
+	methods := scanHandler(h)
+	if err := checkMethods(methods); err != nil {
+		return err
+	}
+	if err := mx.checkDuplicates(methods); err != nil {
+		return err
+	}
+	if err := mx.handlers.checkStrict(methods); err != nil {
+		return err
+	}
+	mx.Register(h)
+	return nil
+}
+
+// checkDuplicates returns an error naming both handlers if one of the handler methods
+// handles an already registered command type.
+func (mx *mux) checkDuplicates(methods []handlerMethod) error {
+	set := mx.handlers.load()
+	for _, m := range methods {
+		if prev, ok := set.entriesFor(m.op).Load(m.cmdType); ok {
+			return fmt.Errorf("%w: %s for %v, already handled by %s",
+				ErrDuplicateHandler, m.name, m.cmdType, prev.(*handler).name)
+		}
+	}
+	return nil
+}
+
+// checkMethods returns an error wrapping ErrDuplicateHandler if several methods of a handler
+// handle the same command type, as only one of them could be executed.
+func checkMethods(methods []handlerMethod) error {
+	seen := make(map[handlerKey]string, len(methods))
+	for _, m := range methods {
+		key := handlerKey{op: m.op, t: m.cmdType}
+		if prev, ok := seen[key]; ok {
+			return fmt.Errorf("%w: %s for %v, also handled by %s", ErrDuplicateHandler, m.name, m.cmdType, prev)
+		}
+		seen[key] = m.name
+	}
+	return nil
+}
+
+// handlerMethod is a handler method found on a registered handler.
+type handlerMethod struct {
+	op      OpType
+	cmdType reflect.Type
+	fn      any
+	// result is the handler returning its result, used by QueryResult, if any.
+	result any
+	name   string
+}
+
+// scanHandler returns the handler methods of the handler.
+func scanHandler(handler any) []handlerMethod {
 	val := reflect.ValueOf(handler)
 	typ := val.Type()
 
@@ -170,49 +649,212 @@ func (mx *mux) Register(handler interface{}) {
 		typ = val.Type()
 	}
 
+	var methods []handlerMethod
 	for i := 0; i < typ.NumMethod(); i++ {
 		method := typ.Method(i)
-		if isHandlerMethod(method) {
-			cmdType := method.Type.In(2).Elem()
-			if cmdType.Implements(reflect.TypeOf((*Action)(nil)).Elem()) ||
-				cmdType.Implements(reflect.TypeOf((*QueryAction)(nil)).Elem()) {
-				mx.addHandler(cmdType, val.Method(i).Interface())
-			}
+		var fn any
+		switch {
+		case isHandlerMethod(method):
+			fn = val.Method(i).Interface()
+		case isFollowUpMethod(method):
+			fn = followUpHandler(val.Method(i), method.Type.In(2).Elem())
+		default:
+			continue
+		}
+		cmdType := method.Type.In(2).Elem()
+		if cmdType.Implements(reflect.TypeOf((*Action)(nil)).Elem()) ||
+			cmdType.Implements(reflect.TypeOf((*QueryAction)(nil)).Elem()) {
+			methods = append(methods, handlerMethod{
+				op:      opTypeOf(cmdType),
+				cmdType: cmdType,
+				fn:      fn,
+				name:    handlerName(val, method),
+			})
 		}
 	}
+	return methods
+}
+
+// skippedMethods describes the methods of the handler that are not handler methods.
+func skippedMethods(handler any) []string {
+	typ := reflect.TypeOf(handler)
+	if typ.Kind() != reflect.Ptr {
+		typ = reflect.PtrTo(typ)
+	}
+	var skipped []string
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if isHandlerMethod(method) || isFollowUpMethod(method) {
+			continue
+		}
+		skipped = append(skipped, method.Name+": "+skipReason(method.Type))
+	}
+	return skipped
+}
+
+// skipReason returns why the method type, including its receiver, is not a handler method.
+func skipReason(m reflect.Type) string {
+	switch {
+	case m.NumIn() != 3:
+		return fmt.Sprintf("takes %d arguments instead of a context.Context and a command pointer", m.NumIn()-1)
+	case !isContextType(m.In(1)):
+		return fmt.Sprintf("first argument is %v instead of context.Context", m.In(1))
+	case m.In(2).Kind() != reflect.Ptr:
+		return fmt.Sprintf("command %v is not passed by pointer", m.In(2))
+	case opTypeOf(m.In(2).Elem()) == ACTION:
+		return fmt.Sprintf("returns %s instead of error or (dew.Command, error)", results(m))
+	default:
+		return fmt.Sprintf("returns %s instead of error, use RegisterResult for a handler returning a result", results(m))
+	}
+}
+
+// results formats the result types of the function type.
+func results(fn reflect.Type) string {
+	out := make([]string, fn.NumOut())
+	for i := range out {
+		out[i] = fn.Out(i).String()
+	}
+	return "(" + strings.Join(out, ", ") + ")"
+}
+
+// RegisterTyped adds the handler function to the bus for the command type T.
+// Unlike Register, it binds the handler to T explicitly without scanning methods.
+func RegisterTyped[T Command](bus Bus, fn func(ctx context.Context, command *T) error) {
+	mx := bus.(*mux)
+	typ := typeFor[T]()
+	m := handlerMethod{op: opTypeOf(typ), cmdType: typ, fn: fn, name: funcName(fn)}
+	if err := mx.handlers.checkStrict([]handlerMethod{m}); err != nil {
+		panic(err)
+	}
+	mx.addHandler(m)
 	mx.setupHandler()
 }
 
 func (mx *mux) setupHandler() {
-	if mx.mHandlers[mQuery] == nil {
-		mx.updateHandler(mQuery)
-	}
-	if mx.mHandlers[mDispatch] == nil {
-		mx.updateHandler(mDispatch)
+	mx.lock.Lock()
+	for _, m := range []middlewareType{mQuery, mDispatch} {
+		if mx.mHandlers[m] == nil {
+			mx.mHandlers[m] = mx.newMHandler(m)
+		}
 	}
+	mx.lock.Unlock()
 	if mx.parent != nil {
 		mx.parent.setupHandler()
 	}
 }
 
-func (mx *mux) addHandler(t reflect.Type, h any) {
-	mx.entries.Store(t, &handler{handler: h, mux: mx})
+func (mx *mux) addHandler(m handlerMethod) {
+	op, t := m.op, m.cmdType
+	mx.handlers.write.Lock()
+	set := mx.handlers.load()
+	entries := set.entriesFor(op)
+	hh := &handler{handler: m.fn, result: m.result, mux: mx, name: m.name, op: op}
+	if prev, ok := entries.Load(t); ok {
+		hh.all = append(append([]*handler{}, prev.(*handler).all...), hh)
+	} else {
+		hh.all = []*handler{hh}
+	}
+	entries.Store(t, hh)
+	// The command type may have been resolved to the previous handler, for either
+	// operation type as queries fall back to the action handlers.
+	for _, op := range []OpType{ACTION, QUERY} {
+		set.cacheFor(op).delete(t)
+	}
+	mx.handlers.write.Unlock()
+	mx.handlers.notifyRegister(t, op, mx)
+}
+
+// OnRegister adds a callback called whenever a handler is registered to the bus or any of its groups.
+func (mx *mux) OnRegister(fn func(cmdType reflect.Type, op OpType, module Bus)) {
+	r := mx.handlers
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onRegister = append(r.onRegister, fn)
+}
+
+// OnUnhandled adds a callback called whenever a command without a handler is dispatched
+// to the bus or any of its groups, before the error is returned.
+func (mx *mux) OnUnhandled(fn func(cmdType reflect.Type, op OpType)) {
+	r := mx.handlers
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onUnhandled = append(r.onUnhandled, fn)
+}
+
+// OnFirstUse adds a callback called the first time a command type is resolved,
+// with the time taken to resolve its handler and build its middleware chain.
+// Use Warmup to build the chains ahead of the first dispatch.
+func (mx *mux) OnFirstUse(fn func(cmdType reflect.Type, buildDur time.Duration)) {
+	r := mx.handlers
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onFirstUse = append(r.onFirstUse, fn)
+}
+
+// firstUse builds the middleware chain of the operation type if needed,
+// and reports the first use of the command type started at start.
+func (mx *mux) firstUse(set *handlerSet, op OpType, t reflect.Type, start time.Time) {
+	if mx.handlerFor(op) == nil {
+		mx.updateRouteHandler(op)
+	}
+	mx.handlers.notifyFirstUse(set, handlerKey{op: op, t: t}, time.Since(start))
+}
+
+// opTypeOf returns ACTION for action types and QUERY otherwise.
+func opTypeOf(t reflect.Type) OpType {
+	if t.Implements(actionType) {
+		return ACTION
+	}
+	return QUERY
+}
+
+// HandlerSnapshot returns a snapshot of the registered handlers.
+func (mx *mux) HandlerSnapshot() *HandlerSnapshot {
+	entries := make(map[handlerKey]*handler)
+	mx.handlers.load().rangeAll(func(key handlerKey, h *handler) bool {
+		entries[key] = h
+		return true
+	})
+	return &HandlerSnapshot{entries: entries}
+}
+
+// RestoreHandlers atomically replaces the registered handlers with the snapshot.
+func (mx *mux) RestoreHandlers(snapshot *HandlerSnapshot) {
+	set := newHandlerSet()
+	for key, h := range snapshot.entries {
+		set.entriesFor(key.op).Store(key.t, h)
+	}
+	mx.handlers.write.Lock()
+	mx.handlers.current.Store(set)
+	mx.handlers.write.Unlock()
+}
+
+// handlerName returns a readable name for the handler method.
+// For function handlers such as HandlerFunc, it returns the name of the function.
+func handlerName(val reflect.Value, method reflect.Method) string {
+	if val.Elem().Kind() == reflect.Func {
+		return funcName(val.Elem().Interface())
+	}
+	return fmt.Sprintf("(%v).%s", val.Type(), method.Name)
 }
 
 // isHandlerMethod checks if the method is a Executor method.
 // A Executor method is a method that has 3 input parameters,
 // the first is the receiver, the second is a context.Context,
-// and the third is a pointer to a struct that implements the Action or QueryAction interface.
+// and the third is a pointer to the command, which can be a struct or a defined type
+// such as `type UserID int`.
 // Example:
 //
 //	func (uh *UserHandler) Update(ctx context.Context, action *action.UpdateUser) error
 func isHandlerMethod(m reflect.Method) bool {
-	return m.Type.NumIn() == 3 && isContextType(m.Type.In(1)) && m.Type.NumOut() == 1 && isErrorType(m.Type.Out(0))
+	return m.Type.NumIn() == 3 && isContextType(m.Type.In(1)) && m.Type.In(2).Kind() == reflect.Ptr &&
+		m.Type.NumOut() == 1 && isErrorType(m.Type.Out(0))
 }
 
 var (
-	ctxType = reflect.TypeOf((*context.Context)(nil)).Elem()
-	errType = reflect.TypeOf((*error)(nil)).Elem()
+	actionType = reflect.TypeOf((*Action)(nil)).Elem()
+	ctxType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errType    = reflect.TypeOf((*error)(nil)).Elem()
 )
 
 func isContextType(t reflect.Type) bool {
@@ -233,6 +875,10 @@ func exec(middlewares []middleware, command Middleware) Middleware {
 		return MiddlewareFunc(func(ctx Context) error {
 			idx := ctx.(*BusContext).mwsIdx
 			if idx < len(middlewares) {
+				// Stop the chain as soon as the context is done.
+				if err := ctx.Context().Err(); err != nil {
+					return err
+				}
 				ctx.(*BusContext).mwsIdx++
 				return middlewares[idx].fn(exec(middlewares, command)).Handle(ctx)
 			}
@@ -249,14 +895,54 @@ func chain(op OpType, middlewares []middleware, command Middleware) Middleware {
 		return command
 	}
 
-	h := mws[len(mws)-1].fn(command)
+	h := observe(len(mws)-1, mws[len(mws)-1].fn, command)
 	for i := len(mws) - 2; i >= 0; i-- {
-		h = mws[i].fn(h)
+		h = observe(i, mws[i].fn, h)
 	}
 
 	return h
 }
 
+// wrap wraps the handler with the wrappers that match the given operation type.
+func wrap(op OpType, wrappers []middleware, handler Middleware) Middleware {
+	ws := filterMiddleware(op, wrappers)
+	for i := len(ws) - 1; i >= 0; i-- {
+		handler = ws[i].fn(handler)
+	}
+	return handler
+}
+
+// observe wraps the middleware at the given depth of the chain so that
+// an error returned without calling next is recorded on the context.
+func observe(depth int, fn func(next Middleware) Middleware, next Middleware) Middleware {
+	name := funcName(fn)
+	h := fn(MiddlewareFunc(func(ctx Context) error {
+		bctx := ctx.(*BusContext)
+		if bctx.reached <= depth {
+			bctx.reached = depth + 1
+		}
+		return next.Handle(ctx)
+	}))
+	return MiddlewareFunc(func(ctx Context) error {
+		err := h.Handle(ctx)
+		if err != nil {
+			bctx := ctx.(*BusContext)
+			if bctx.reached <= depth && bctx.shortCircuitedBy == "" {
+				bctx.shortCircuitedBy = name
+			}
+		}
+		return err
+	})
+}
+
+// funcName returns the fully qualified name of the given function.
+func funcName(fn any) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
 // filterMiddleware returns the middlewares that match the given operation type.
 func filterMiddleware(op OpType, middlewares []middleware) []middleware {
 	var mws []middleware
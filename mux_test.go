@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -35,6 +38,165 @@ func TestMux_BasicCommand(t *testing.T) {
 	}
 }
 
+func TestDo(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	action := &createUser{Name: "john"}
+	if err := dew.Do(ctx, action); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action.Result != "user created" {
+		t.Fatalf("unexpected result: %s", action.Result)
+	}
+
+	if err := dew.Do(ctx, &createUser{}); !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDoR(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	result, err := dew.DoR(ctx, &createUser{Name: "john"}, func(c *createUser) string { return c.Result })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "user created" {
+		t.Fatalf("unexpected result: %s", result)
+	}
+
+	result, err = dew.DoR(ctx, &createUser{}, func(c *createUser) string { return c.Result })
+	if !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Fatalf("expected zero result, got: %s", result)
+	}
+}
+
+func TestDispatchTo(t *testing.T) {
+	newBus := func(name string) dew.Bus {
+		mux := dew.New()
+		mux.Register(dew.HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error {
+			cmd.Result = name
+			return nil
+		}))
+		mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+			query.Result = name
+			return nil
+		}))
+		return mux
+	}
+	primary, secondary := newBus("primary"), newBus("secondary")
+	ctx := dew.NewContext(context.Background(), primary)
+
+	action, err := dew.DispatchTo(ctx, secondary, &createUser{Name: "john"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action.Result != "secondary" {
+		t.Fatalf("unexpected result: %s", action.Result)
+	}
+
+	query, err := dew.QueryTo(ctx, secondary, &findUser{ID: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Result != "secondary" {
+		t.Fatalf("unexpected result: %s", query.Result)
+	}
+
+	action, err = dew.Dispatch(ctx, &createUser{Name: "john"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action.Result != "primary" {
+		t.Fatalf("unexpected result: %s", action.Result)
+	}
+}
+
+func TestDispatchTo_Group(t *testing.T) {
+	mux := dew.New()
+	var group, clean dew.Bus
+	mux.Group(func(mx dew.Bus) {
+		group = mx
+		mx.Register(new(userHandler))
+	})
+	clean = mux.CleanGroup(nil)
+	ctx := dew.NewContext(context.Background(), mux)
+
+	for name, target := range map[string]dew.Bus{"group": group, "clean group": clean} {
+		t.Run(name, func(t *testing.T) {
+			action, err := dew.DispatchTo(ctx, target, &createUser{Name: "john"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if action.Result != "user created" {
+				t.Fatalf("unexpected result: %s", action.Result)
+			}
+			query, err := dew.QueryTo(ctx, target, &findUser{ID: 1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if query.Result != "john" {
+				t.Fatalf("unexpected result: %s", query.Result)
+			}
+		})
+	}
+
+	// the bus returned by ResolveHandler can be targeted
+	target, err := dew.ResolveHandler(mux, &createUser{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.DispatchTo(ctx, target, &createUser{Name: "john"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDispatchDetached(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error {
+		if cmd.Name == "" {
+			return errNameRequired
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cmd.Result = cmd.Name
+		return nil
+	}))
+
+	for name, tc := range map[string]struct {
+		action *createUser
+		err    error
+	}{
+		"Success": {action: &createUser{Name: "john"}},
+		"Error":   {action: &createUser{}, err: errNameRequired},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The request is over before the action runs.
+			ctx, cancel := context.WithCancel(dew.NewContext(context.Background(), mux))
+			cancel()
+
+			done := make(chan error, 1)
+			dew.DispatchDetached(ctx, tc.action, func(err error) {
+				done <- err
+			})
+			if err := <-done; !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.err == nil && tc.action.Result != "john" {
+				t.Fatalf("unexpected result: %s", tc.action.Result)
+			}
+		})
+	}
+}
+
 func TestMux_DispatchError(t *testing.T) {
 	t.Run("BusNotFound", func(t *testing.T) {
 		ctx := context.Background()
@@ -42,7 +204,7 @@ func TestMux_DispatchError(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected an error, but got nil")
 		}
-		if !strings.Contains(err.Error(), "bus not found") {
+		if !errors.Is(err, dew.ErrBusNotInContext) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -53,7 +215,7 @@ func TestMux_DispatchError(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected an error, but got nil")
 		}
-		if !strings.Contains(err.Error(), "handler not found") {
+		if !errors.Is(err, dew.ErrHandlerNotFound) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -65,7 +227,7 @@ func TestMux_QueryError(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected an error, but got nil")
 		}
-		if !strings.Contains(err.Error(), "bus not found") {
+		if !errors.Is(err, dew.ErrBusNotFound) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -76,19 +238,42 @@ func TestMux_QueryError(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected an error, but got nil")
 		}
-		if !strings.Contains(err.Error(), "handler not found") {
+		if !errors.Is(err, dew.ErrHandlerNotFound) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
 }
 
+func TestMux_ReentrantBusNotInContext(t *testing.T) {
+	type findUserPost struct {
+		ID int
+	}
+
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(dew.HandlerFunc[findUserPost](func(ctx context.Context, query *findUserPost) error {
+		// The context used for the re-entrant query is not derived from the handler context.
+		_, err := dew.Query(context.WithValue(context.Background(), ctxKey{"name"}, "john"), &findUser{ID: query.ID})
+		return err
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	_, err := dew.Query(ctx, &findUserPost{ID: 1})
+	if !errors.Is(err, dew.ErrBusNotInContext) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("expected the error to be distinct from handler not found: %v", err)
+	}
+}
+
 func TestMux_QueryAsyncError(t *testing.T) {
 	t.Run("BusNotFound", func(t *testing.T) {
 		err := dew.QueryAsync(context.Background(), dew.NewQuery(&findUser{ID: 1}))
 		if err == nil {
 			t.Fatal("expected an error, but got nil")
 		}
-		if !strings.Contains(err.Error(), "bus not found") {
+		if !errors.Is(err, dew.ErrBusNotInContext) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -99,12 +284,35 @@ func TestMux_QueryAsyncError(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected an error, but got nil")
 		}
-		if !strings.Contains(err.Error(), "handler not found") {
+		if !errors.Is(err, dew.ErrHandlerNotFound) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
 }
 
+func TestMux_NilCommand(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, err := dew.Dispatch[createUser](ctx, nil); !errors.Is(err, dew.ErrNilCommand) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := dew.DispatchMulti(ctx,
+		dew.NewAction(&createUser{Name: "john"}),
+		dew.NewAction[createUser](nil),
+	)
+	if !errors.Is(err, dew.ErrNilCommand) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.Query[findUser](ctx, nil); !errors.Is(err, dew.ErrNilCommand) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := dew.QueryAsync(ctx, dew.NewQuery[findUser](nil)); !errors.Is(err, dew.ErrNilCommand) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestMux_ValueTypeHandler(t *testing.T) {
 	var userHandler userHandler
 
@@ -119,6 +327,244 @@ func TestMux_ValueTypeHandler(t *testing.T) {
 	}
 }
 
+type userID int
+
+type userEmail string
+
+func (e userEmail) Validate(_ context.Context) error {
+	if e == "" {
+		return errNameRequired
+	}
+	return nil
+}
+
+type definedTypeHandler struct {
+	sent []userEmail
+}
+
+func (h *definedTypeHandler) Normalize(_ context.Context, id *userID) error {
+	*id += 1000
+	return nil
+}
+
+func (h *definedTypeHandler) SendEmail(_ context.Context, email *userEmail) error {
+	h.sent = append(h.sent, *email)
+	return nil
+}
+
+// Count is not a handler method as its command is not a pointer.
+func (h *definedTypeHandler) Count(_ context.Context, n int) error {
+	return nil
+}
+
+func TestMux_DefinedTypeCommand(t *testing.T) {
+	h := &definedTypeHandler{}
+
+	mux := dew.New()
+	mux.Register(h)
+	ctx := dew.NewContext(context.Background(), mux)
+
+	id := userID(1)
+	if result := testRunQuery(t, ctx, &id); *result != 1001 {
+		t.Fatalf("unexpected result: %d", *result)
+	}
+
+	email := userEmail("john@example.com")
+	testRunDispatch(t, ctx, dew.NewAction(&email))
+	if len(h.sent) != 1 || h.sent[0] != email {
+		t.Fatalf("unexpected emails: %v", h.sent)
+	}
+
+	empty := userEmail("")
+	if _, err := dew.Dispatch(ctx, &empty); !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len(mux.Handlers()); got != 2 {
+		t.Fatalf("unexpected number of handlers: %d", got)
+	}
+}
+
+// externalCreateUser is a function written without the bus.
+func externalCreateUser(_ context.Context, req *createUser) (*createUser, error) {
+	if req.Name == "error" {
+		return nil, errNameRequired
+	}
+	return &createUser{Name: req.Name, Result: "created by " + req.Name}, nil
+}
+
+func TestAdapt(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.Adapt(externalCreateUser))
+	mux.Register(dew.Adapt(func(_ context.Context, req *findUser) (*findUser, error) {
+		req.Result = "john"
+		return req, nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	action := &createUser{Name: "john"}
+	testRunDispatch(t, ctx, dew.NewAction(action))
+	if action.Result != "created by john" {
+		t.Fatalf("unexpected result: %s", action.Result)
+	}
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "error"}); !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type ambiguousUserHandler struct{}
+
+func (ambiguousUserHandler) CreateUser(_ context.Context, command *createUser) error {
+	command.Result = "create"
+	return nil
+}
+
+func (ambiguousUserHandler) ImportUser(_ context.Context, command *createUser) error {
+	command.Result = "import"
+	return nil
+}
+
+func TestRegisterTyped(t *testing.T) {
+	var h ambiguousUserHandler
+
+	mux := dew.New()
+	dew.RegisterTyped(mux, h.ImportUser)
+	dew.RegisterTyped(mux, func(_ context.Context, query *findUser) error {
+		query.Result = "typed"
+		return nil
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	createUser := &createUser{Name: "john"}
+	testRunDispatch(t, ctx, dew.NewAction(createUser))
+	if createUser.Result != "import" {
+		t.Fatalf("unexpected result: %s", createUser.Result)
+	}
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "typed" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
+
+type conflictingUserHandler struct{}
+
+func (conflictingUserHandler) FindUser(_ context.Context, query *findUser) error {
+	query.Result = "conflict"
+	return nil
+}
+
+func TestMux_RegisterMany(t *testing.T) {
+	mux := dew.New()
+	err := mux.RegisterMany(
+		new(userHandler),
+		conflictingUserHandler{},
+		new(postHandler),
+		nil,
+	)
+	if !errors.Is(err, dew.ErrDuplicateHandler) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "(*dew_test.conflictingUserHandler).FindUser for dew_test.findUser") {
+		t.Fatalf("expected the error to name the conflicting handler: %v", err)
+	}
+	if !strings.Contains(err.Error(), "register <nil>") {
+		t.Fatalf("expected the error to report the invalid handler: %v", err)
+	}
+
+	// valid handlers are registered and the conflicting one is skipped
+	ctx := dew.NewContext(context.Background(), mux)
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if result := testRunQuery(t, ctx, &findPost{ID: 1}); result.Result != "hello" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
+
+type miswiredHandler struct{}
+
+func (miswiredHandler) CreateUsr(_ context.Context, command createUser) error {
+	return nil
+}
+
+func (miswiredHandler) FindUser(_ context.Context, query *findUser) string {
+	return ""
+}
+
+func TestMux_RegisterChecked(t *testing.T) {
+	mux := dew.New()
+	if err := mux.RegisterChecked(new(userHandler)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := mux.RegisterChecked(miswiredHandler{})
+	if !errors.Is(err, dew.ErrNoHandlerMethods) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, reason := range []string{
+		"CreateUsr: command dew_test.createUser is not passed by pointer",
+		"FindUser: returns (string) instead of error, use RegisterResult",
+	} {
+		if !strings.Contains(err.Error(), reason) {
+			t.Fatalf("expected the error to report %q: %v", reason, err)
+		}
+	}
+	if err := mux.RegisterChecked(nil); !errors.Is(err, dew.ErrNoHandlerMethods) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = mux.RegisterChecked(conflictingUserHandler{})
+	if !errors.Is(err, dew.ErrDuplicateHandler) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "(*dew_test.conflictingUserHandler).FindUser for dew_test.findUser") ||
+		!strings.Contains(err.Error(), "already handled by (*dew_test.userHandler).FindUser") {
+		t.Fatalf("expected the error to name both handlers: %v", err)
+	}
+
+	// the handlers of the valid handler are registered, and the conflicting one is not
+	ctx := dew.NewContext(context.Background(), mux)
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
+
+func TestMux_OnRegister(t *testing.T) {
+	type registration struct {
+		cmdType reflect.Type
+		op      dew.OpType
+		module  dew.Bus
+	}
+	var registrations []registration
+
+	mux := dew.New()
+	mux.OnRegister(func(cmdType reflect.Type, op dew.OpType, module dew.Bus) {
+		registrations = append(registrations, registration{cmdType, op, module})
+	})
+	mux.Register(new(postHandler))
+	group := mux.Group(func(mux dew.Bus) {
+		mux.Register(dew.HandlerFunc[findUser](
+			func(ctx context.Context, query *findUser) error { return nil },
+		))
+	})
+
+	expected := []registration{
+		{reflect.TypeOf(createPost{}), dew.ACTION, mux},
+		{reflect.TypeOf(findPost{}), dew.QUERY, mux},
+		{reflect.TypeOf(findUser{}), dew.QUERY, group},
+	}
+	if len(registrations) != len(expected) {
+		t.Fatalf("unexpected registrations: %v", registrations)
+	}
+	for i, r := range registrations {
+		if r != expected[i] {
+			t.Errorf("unexpected registration #%d: %v", i, r)
+		}
+	}
+}
+
 func TestMux_HandlerNotFound(t *testing.T) {
 	mux := dew.New()
 	ctx := dew.NewContext(context.Background(), mux)
@@ -130,6 +576,64 @@ func TestMux_HandlerNotFound(t *testing.T) {
 	}
 }
 
+func TestMux_OnUnhandled(t *testing.T) {
+	type unhandled struct {
+		cmdType reflect.Type
+		op      dew.OpType
+	}
+	var got []unhandled
+	mux := dew.New()
+	mux.OnUnhandled(func(cmdType reflect.Type, op dew.OpType) {
+		got = append(got, unhandled{cmdType, op})
+	})
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "john"}); err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+	testRunQuery(t, ctx, &findPost{ID: 1})
+
+	if len(got) != 2 {
+		t.Fatalf("unexpected unhandled commands: %v", got)
+	}
+	if got[0].cmdType != reflect.TypeOf(createUser{}) || got[0].op != dew.ACTION {
+		t.Fatalf("unexpected unhandled action: %v", got[0])
+	}
+	if got[1].cmdType != reflect.TypeOf(findUser{}) || got[1].op != dew.QUERY {
+		t.Fatalf("unexpected unhandled query: %v", got[1])
+	}
+}
+
+func TestMux_OnFirstUse(t *testing.T) {
+	uses := map[reflect.Type]int{}
+	mux := dew.New()
+	mux.OnFirstUse(func(cmdType reflect.Type, buildDur time.Duration) {
+		if buildDur < 0 {
+			t.Errorf("unexpected build duration: %v", buildDur)
+		}
+		uses[cmdType]++
+	})
+	mux.Use(dew.ALL, passThrough)
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	for i := 0; i < 3; i++ {
+		testRunQuery(t, ctx, &findUser{ID: 1})
+		testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	}
+	if err := dew.QueryAsync(ctx, dew.NewQuery(&findUser{ID: 1}), dew.NewQuery(&findUser{ID: 1})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(uses) != 2 || uses[reflect.TypeOf(findUser{})] != 1 || uses[reflect.TypeOf(createUser{})] != 1 {
+		t.Fatalf("unexpected first uses: %v", uses)
+	}
+}
+
 func TestMux_Query(t *testing.T) {
 	mux := dew.New()
 	mux.Register(new(userHandler))
@@ -151,6 +655,30 @@ func TestMux_Query(t *testing.T) {
 	}
 }
 
+type validatedQuery struct {
+	Result string
+}
+
+func (validatedQuery) Validate(context.Context) error { return nil }
+
+func TestMux_QueryWithValidate(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[validatedQuery](func(_ context.Context, query *validatedQuery) error {
+		query.Result = "found"
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// A query type with a Validate method is still handled as a query.
+	result, err := dew.Query(ctx, &validatedQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Result != "found" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
+
 func TestMux_QueryAsync(t *testing.T) {
 	mux := dew.New()
 
@@ -232,6 +760,255 @@ func TestMux_QueryAsync(t *testing.T) {
 	}
 }
 
+func TestQueryAsyncWithLimit(t *testing.T) {
+	var running, peak atomic.Int32
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if query.ID == 0 {
+			return errUserNotFound
+		}
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	queries := make([]dew.CommandHandler[dew.Command], 10)
+	for i := range queries {
+		queries[i] = dew.NewQuery(&findUser{ID: i})
+	}
+	err := dew.QueryAsyncWithLimit(ctx, 3, queries...)
+	var cmdErr *dew.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Index != 0 || !errors.Is(err, errUserNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if peak.Load() > 3 {
+		t.Fatalf("expected at most 3 concurrent queries, got %d", peak.Load())
+	}
+
+	peak.Store(0)
+	if err := dew.QueryAsyncWithLimit(ctx, 0, queries[1:]...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if peak.Load() < 2 {
+		t.Fatalf("expected unbounded concurrency, got %d", peak.Load())
+	}
+}
+
+func TestWithFailFast(t *testing.T) {
+	type slowQuery struct {
+		Completed bool
+	}
+
+	newMux := func(opts ...dew.Option) dew.Bus {
+		mux := dew.New(opts...)
+		mux.Register(new(userHandler))
+		mux.Register(dew.HandlerFunc[slowQuery](func(ctx context.Context, query *slowQuery) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+				query.Completed = true
+				return nil
+			}
+		}))
+		return mux
+	}
+
+	// by default, the other queries run to completion
+	ctx := dew.NewContext(context.Background(), newMux())
+	slow := &slowQuery{}
+	err := dew.QueryAsync(ctx, dew.NewQuery(slow), dew.NewQuery(&findUser{ID: 2}))
+	if !errors.Is(err, errUserNotFound) || !slow.Completed {
+		t.Fatalf("unexpected result: %v, completed: %v", err, slow.Completed)
+	}
+
+	// the first error cancels the other queries
+	ctx = dew.NewContext(context.Background(), newMux(dew.WithFailFast()))
+	slow = &slowQuery{}
+	err = dew.QueryAsync(ctx, dew.NewQuery(slow), dew.NewQuery(&findUser{ID: 2}))
+	if !errors.Is(err, errUserNotFound) || errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slow.Completed {
+		t.Fatalf("the slow query was not cancelled")
+	}
+
+	// the queries not started yet are skipped
+	slow = &slowQuery{}
+	err = dew.QueryAsyncWithLimit(ctx, 1, dew.NewQuery(&findUser{ID: 2}), dew.NewQuery(slow))
+	if !errors.Is(err, errUserNotFound) || slow.Completed {
+		t.Fatalf("unexpected result: %v, completed: %v", err, slow.Completed)
+	}
+
+	// a cancelled parent context skips the queries
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = dew.QueryAsyncWithLimit(cctx, 1, dew.NewQuery(&slowQuery{}), dew.NewQuery(&slowQuery{}))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIsAsync(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+		query.Result = fmt.Sprint(dew.IsAsync(ctx))
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "false" {
+		t.Fatalf("expected IsAsync to be false in Query, got %s", result.Result)
+	}
+
+	query := &findUser{ID: 1}
+	if err := dew.QueryAsync(ctx, dew.NewQuery(query), dew.NewQuery(&findUser{ID: 2})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Result != "true" {
+		t.Fatalf("expected IsAsync to be true in QueryAsync, got %s", query.Result)
+	}
+	if dew.IsAsync(ctx) {
+		t.Fatal("expected IsAsync to be false for the caller context")
+	}
+}
+
+func TestMux_QueryAsyncCancelOnReturn(t *testing.T) {
+	mux := dew.New()
+	started := make(chan context.Context, 2)
+	mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+		// Hand the context over to background work that outlives the handler.
+		started <- ctx
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if err := dew.QueryAsync(ctx, dew.NewQuery(&findUser{ID: 1}), dew.NewQuery(&findUser{ID: 2})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expected the caller context not to be cancelled")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-(<-started).Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected the query context to be cancelled when QueryAsync returns")
+		}
+	}
+}
+
+func TestDispatchAsync(t *testing.T) {
+	var dispatchCount, handled atomic.Int32
+	mux := dew.New()
+	mux.UseDispatch(func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			dispatchCount.Add(1)
+			return next.Handle(ctx)
+		})
+	})
+	mux.Register(dew.HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error {
+		handled.Add(1)
+		if cmd.Name == "fail" {
+			return errNameRequired
+		}
+		cmd.Result = cmd.Name
+		return nil
+	}))
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	actions := []*createUser{{Name: "john"}, {Name: "jane"}}
+	if err := dew.DispatchAsync(ctx, dew.NewAction(actions[0]), dew.NewAction(actions[1])); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, action := range actions {
+		if action.Result != action.Name {
+			t.Fatalf("unexpected result: %s", action.Result)
+		}
+	}
+	if dispatchCount.Load() != 1 {
+		t.Fatalf("unexpected middleware call count: %d", dispatchCount.Load())
+	}
+
+	err := dew.DispatchAsync(ctx, dew.NewAction(&createUser{Name: "john"}), dew.NewAction(&createUser{Name: "fail"}))
+	var cmdErr *dew.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Index != 1 || !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Invalid actions fail before any action is executed.
+	handled.Store(0)
+	if err := dew.DispatchAsync(ctx, dew.NewAction(&createUser{Name: "john"}), dew.NewAction(&createPost{})); !errors.Is(err, dew.ErrValidationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if handled.Load() != 0 {
+		t.Fatalf("expected no action to be executed, got %d", handled.Load())
+	}
+}
+
+func TestMux_UseQueryBatch(t *testing.T) {
+	var executed atomic.Int32
+
+	mux := dew.New()
+	mux.UseQueryBatch(func(ctx dew.Context, queries []dew.CommandHandler[dew.Command]) ([]dew.CommandHandler[dew.Command], error) {
+		seen := make(map[int]bool)
+		var unique []dew.CommandHandler[dew.Command]
+		for _, q := range queries {
+			if query, ok := q.Command().(*findUser); ok {
+				if seen[query.ID] {
+					continue
+				}
+				seen[query.ID] = true
+			}
+			unique = append(unique, q)
+		}
+		return unique, nil
+	})
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			executed.Add(1)
+			query.Result = fmt.Sprintf("user-%d", query.ID)
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	err := dew.QueryAsync(ctx,
+		dew.NewQuery(&findUser{ID: 1}),
+		dew.NewQuery(&findUser{ID: 2}),
+		dew.NewQuery(&findUser{ID: 1}),
+		dew.NewQuery(&findUser{ID: 2}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if executed.Load() != 2 {
+		t.Fatalf("unexpected executions: %d", executed.Load())
+	}
+
+	// the batch function can abort the batch
+	errTooMany := errors.New("too many queries")
+	mux.UseQueryBatch(func(ctx dew.Context, queries []dew.CommandHandler[dew.Command]) ([]dew.CommandHandler[dew.Command], error) {
+		if len(queries) > 1 {
+			return nil, errTooMany
+		}
+		return queries, nil
+	})
+	err = dew.QueryAsync(ctx, dew.NewQuery(&findUser{ID: 1}), dew.NewQuery(&findUser{ID: 2}))
+	if !errors.Is(err, errTooMany) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestMux_QueryAsync_Error(t *testing.T) {
 	mux := dew.New()
 
@@ -245,58 +1022,231 @@ func TestMux_QueryAsync_Error(t *testing.T) {
 			return errUserNotFound
 		},
 	))
-
-	mux.Register(dew.HandlerFunc[findPost](
-		func(ctx context.Context, query *findPost) error {
-			return errPostNotFound
+
+	mux.Register(dew.HandlerFunc[findPost](
+		func(ctx context.Context, query *findPost) error {
+			return errPostNotFound
+		},
+	))
+
+	ctx := dew.NewContext(context.Background(), mux)
+
+	commands := dew.Commands{
+		dew.NewQuery(&findUser{ID: 1}),
+		dew.NewQuery(&findPost{ID: 1}),
+	}
+
+	// query
+	err := dew.QueryAsync(ctx, commands...)
+	if err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+	if !errors.Is(err, errUserNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, errPostNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "dew_test.findUser #0: user not found") {
+		t.Fatalf("expected the error to name its query: %v", err)
+	}
+	if !strings.Contains(err.Error(), "dew_test.findPost #1: post not found") {
+		t.Fatalf("expected the error to name its query: %v", err)
+	}
+	var cmdErr *dew.CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expected a command error: %v", err)
+	}
+}
+
+type queryErrors struct {
+	Errors []error
+}
+
+func (e *queryErrors) Error() string {
+	return fmt.Sprintf("%d queries failed", len(e.Errors))
+}
+
+func TestMux_WithErrorAggregator(t *testing.T) {
+	newMux := func(opts ...dew.Option) context.Context {
+		mux := dew.New(opts...)
+		mux.Register(dew.HandlerFunc[findUser](
+			func(ctx context.Context, query *findUser) error {
+				time.Sleep(time.Duration(query.ID) * 10 * time.Millisecond)
+				return fmt.Errorf("user %d: %w", query.ID, errUserNotFound)
+			},
+		))
+		return dew.NewContext(context.Background(), mux)
+	}
+	queries := func() []dew.CommandHandler[dew.Command] {
+		return dew.Commands{dew.NewQuery(&findUser{ID: 1}), dew.NewQuery(&findUser{ID: 2})}
+	}
+
+	t.Run("FirstError", func(t *testing.T) {
+		ctx := newMux(dew.WithErrorAggregator(func(errs []error) error {
+			return errs[0]
+		}))
+		err := dew.QueryAsync(ctx, queries()...)
+		if err == nil || !strings.Contains(err.Error(), "user 1") || strings.Contains(err.Error(), "user 2") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Structured", func(t *testing.T) {
+		ctx := newMux(dew.WithErrorAggregator(func(errs []error) error {
+			return &queryErrors{Errors: errs}
+		}))
+		err := dew.QueryAsync(ctx, queries()...)
+		var qerr *queryErrors
+		if !errors.As(err, &qerr) || len(qerr.Errors) != 2 {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestMux_Reentrant(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+
+	type findUserPost struct {
+		ID     int
+		Result struct {
+			User string
+			Post string
+		}
+	}
+
+	mux.Register(dew.HandlerFunc[findUserPost](
+		func(ctx context.Context, query *findUserPost) error {
+			findUserQuery, err := dew.Query(ctx, &findUser{ID: query.ID})
+			if err != nil {
+				return err
+			}
+			postQuery, err := dew.Query(ctx, &findPost{ID: query.ID})
+			if err != nil {
+				return err
+			}
+			query.Result.User = findUserQuery.Result
+			query.Result.Post = postQuery.Result
+			return nil
+		},
+	))
+
+	ctx := dew.NewContext(context.Background(), mux)
+
+	query, err := dew.Query(ctx, &findUserPost{ID: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Result.User != "john" {
+		t.Fatalf("unexpected result: %s", query.Result.User)
+	}
+	if query.Result.Post != "hello" {
+		t.Fatalf("unexpected result: %s", query.Result.Post)
+	}
+}
+
+func TestMux_ReentrantCancellation(t *testing.T) {
+	type slowQuery struct{}
+	type parentQuery struct{}
+
+	mux := dew.New()
+	mux.Use(dew.QUERY, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			return next.Handle(ctx.WithValue(ctxKey{"mw"}, "enriched"))
+		})
+	})
+	mux.Register(dew.HandlerFunc[slowQuery](
+		func(ctx context.Context, query *slowQuery) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+				return nil
+			}
+		},
+	))
+	mux.Register(dew.HandlerFunc[parentQuery](
+		func(ctx context.Context, query *parentQuery) error {
+			if ctx.Value(ctxKey{"mw"}) != "enriched" {
+				t.Error("expected middleware-enriched context")
+			}
+			_, err := dew.Query(ctx, &slowQuery{})
+			return err
 		},
 	))
 
-	ctx := dew.NewContext(context.Background(), mux)
-
-	commands := dew.Commands{
-		dew.NewQuery(&findUser{ID: 1}),
-		dew.NewQuery(&findPost{ID: 1}),
-	}
+	ctx, cancel := context.WithTimeout(dew.NewContext(context.Background(), mux), 50*time.Millisecond)
+	defer cancel()
 
-	// query
-	err := dew.QueryAsync(ctx, commands...)
-	if err == nil {
-		t.Fatal("expected an error, but got nil")
-	}
-	if !errors.Is(err, errUserNotFound) {
+	now := time.Now()
+	_, err := dew.Query(ctx, &parentQuery{})
+	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !errors.Is(err, errPostNotFound) {
-		t.Fatalf("unexpected error: %v", err)
+	if d := time.Since(now); d > 500*time.Millisecond {
+		t.Fatalf("sub-query did not observe cancellation: %v", d)
 	}
 }
 
-func TestMux_Reentrant(t *testing.T) {
-	mux := dew.New()
-	mux.Register(new(userHandler))
-	mux.Register(new(postHandler))
-
+func TestQueryAllFromHandler(t *testing.T) {
+	type barrierQuery struct {
+		Result string
+	}
 	type findUserPost struct {
 		ID     int
 		Result struct {
-			User string
-			Post string
+			User    string
+			Post    string
+			Barrier string
 		}
 	}
 
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+
+	var queryMiddlewares atomic.Int32
+	mux.UseQuery(func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			queryMiddlewares.Add(1)
+			return next.Handle(ctx)
+		})
+	})
+
+	// both barrier queries must run at the same time to complete
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	mux.Register(dew.HandlerFunc[barrierQuery](
+		func(ctx context.Context, query *barrierQuery) error {
+			barrier.Done()
+			done := make(chan struct{})
+			go func() {
+				barrier.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				query.Result = "met"
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("queries did not run concurrently")
+			}
+		},
+	))
 	mux.Register(dew.HandlerFunc[findUserPost](
 		func(ctx context.Context, query *findUserPost) error {
-			findUserQuery, err := dew.Query(ctx, &findUser{ID: query.ID})
-			if err != nil {
-				return err
-			}
-			postQuery, err := dew.Query(ctx, &findPost{ID: query.ID})
-			if err != nil {
+			user, post := &findUser{ID: query.ID}, &findPost{ID: query.ID}
+			b1, b2 := &barrierQuery{}, &barrierQuery{}
+			if err := dew.QueryAllFromHandler(ctx,
+				dew.NewQuery(user), dew.NewQuery(post), dew.NewQuery(b1), dew.NewQuery(b2),
+			); err != nil {
 				return err
 			}
-			query.Result.User = findUserQuery.Result
-			query.Result.Post = postQuery.Result
+			query.Result.User = user.Result
+			query.Result.Post = post.Result
+			query.Result.Barrier = b1.Result + "," + b2.Result
 			return nil
 		},
 	))
@@ -307,11 +1257,18 @@ func TestMux_Reentrant(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if query.Result.User != "john" {
-		t.Fatalf("unexpected result: %s", query.Result.User)
+	if query.Result.User != "john" || query.Result.Post != "hello" || query.Result.Barrier != "met,met" {
+		t.Fatalf("unexpected result: %+v", query.Result)
 	}
-	if query.Result.Post != "hello" {
-		t.Fatalf("unexpected result: %s", query.Result.Post)
+	// the query middlewares ran once for the request
+	if n := queryMiddlewares.Load(); n != 1 {
+		t.Fatalf("expected the query middlewares to run once, got %d", n)
+	}
+
+	// errors of the queries are returned
+	err = dew.QueryAllFromHandler(ctx, dew.NewQuery(&findUser{ID: 2}))
+	if !errors.Is(err, errUserNotFound) {
+		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
@@ -436,6 +1393,36 @@ func TestMux_DispatchMiddlewares(t *testing.T) {
 	}
 }
 
+func TestMux_DispatchMiddlewaresCancellation(t *testing.T) {
+	mux := dew.New()
+	var calls []string
+
+	mux.UseDispatch(func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			calls = append(calls, "first")
+			c, cancel := context.WithCancel(ctx.Context())
+			cancel()
+			return next.Handle(ctx.WithContext(c))
+		})
+	})
+	mux.UseDispatch(func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			calls = append(calls, "second")
+			return next.Handle(ctx)
+		})
+	})
+	mux.Register(new(userHandler))
+
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "john"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "first" {
+		t.Fatalf("unexpected middleware calls: %v", calls)
+	}
+}
+
 func TestMux_QueryMiddlewares(t *testing.T) {
 	mux := dew.New()
 	var dispatchCount atomic.Int32
@@ -559,6 +1546,166 @@ func TestMux_Groups(t *testing.T) {
 	}
 }
 
+func TestResolveHandler(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(postHandler))
+	group := mux.Group(func(mux dew.Bus) {
+		mux.Register(new(userHandler))
+	})
+
+	bus, err := dew.ResolveHandler(mux, &createUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bus != group {
+		t.Fatalf("expected the group, got: %v", bus)
+	}
+
+	bus, err = dew.ResolveHandler(mux, &findPost{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bus != mux {
+		t.Fatalf("expected the root bus, got: %v", bus)
+	}
+
+	if _, err := dew.ResolveHandler(mux, &updateUser{}); err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+}
+
+func TestMux_ConcurrentGroups(t *testing.T) {
+	mux := dew.New()
+	mw := func(next dew.Middleware) dew.Middleware { return next }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			mux.Group(func(mux dew.Bus) {
+				mux.Use(dew.ALL, mw)
+				mux.Group(func(mux dew.Bus) {
+					mux.UseQuery(mw)
+					mux.Register(new(userHandler))
+				})
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			mux.Use(dew.ALL, mw)
+			mux.UseDispatch(mw)
+			mux.UseQuery(mw)
+			mux.UseHandlerWrapper(dew.QUERY, mw)
+			_ = mux.MiddlewareDepth(dew.ALL)
+		}()
+	}
+	wg.Wait()
+
+	ctx := dew.NewContext(context.Background(), mux)
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
+
+func TestMux_GroupCount(t *testing.T) {
+	mux := dew.New()
+	if n := mux.GroupCount(); n != 0 {
+		t.Fatalf("unexpected group count: %d", n)
+	}
+
+	mux.Group(func(mux dew.Bus) {
+		mux.Group(nil)
+		mux.CleanGroup(nil)
+	})
+	clean := mux.CleanGroup(nil)
+	for i := 0; i < 3; i++ {
+		clean.Group(nil)
+	}
+
+	if n := mux.GroupCount(); n != 7 {
+		t.Fatalf("unexpected group count: %d", n)
+	}
+	if n := clean.GroupCount(); n != 3 {
+		t.Fatalf("unexpected group count: %d", n)
+	}
+}
+
+func TestMux_GroupRelease(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// groups created per request are released once the request is over
+	const n = 1000
+	var released atomic.Int64
+	for i := 0; i < n; i++ {
+		// The group is part of a reference cycle, so the finalizer is set on a value
+		// referenced by the group only.
+		sentinel := new([32]byte)
+		runtime.SetFinalizer(sentinel, func(*[32]byte) { released.Add(1) })
+		group := mux.Group(func(mux dew.Bus) {
+			mux.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
+				runtime.KeepAlive(sentinel)
+				return next
+			})
+		})
+		if _, err := dew.DispatchTo(ctx, group, &createUser{Name: "john"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < 100 && released.Load() < n; i++ {
+		runtime.GC()
+		runtime.Gosched()
+	}
+	if r := released.Load(); r != n {
+		t.Fatalf("expected %d released groups, got %d", n, r)
+	}
+	if c := mux.GroupCount(); c != n {
+		t.Fatalf("unexpected group count: %d", c)
+	}
+}
+
+func TestMux_CleanGroup(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			return next.Handle(ctx.WithValue(ctxKey{"global"}, "[global]"))
+		})
+	})
+	mux.Register(new(postHandler))
+	mux.CleanGroup(func(mux dew.Bus) {
+		mux.Use(dew.ACTION, func(next dew.Middleware) dew.Middleware {
+			return dew.MiddlewareFunc(func(ctx dew.Context) error {
+				return next.Handle(ctx.WithValue(ctxKey{"local"}, "[system]"))
+			})
+		})
+		mux.Register(dew.HandlerFunc[createUser](
+			func(ctx context.Context, command *createUser) error {
+				if ctx.Value(ctxKey{"global"}) != nil {
+					command.Result = "[global]"
+				}
+				command.Result += ctx.Value(ctxKey{"local"}).(string)
+				return nil
+			},
+		))
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	createUser := &createUser{Name: "john"}
+	testRunDispatch(t, ctx, dew.NewAction(createUser))
+	if createUser.Result != "[system]" {
+		t.Fatalf("unexpected result: %s", createUser.Result)
+	}
+
+	// handlers registered to the parent are shared
+	createPost := &createPost{Title: "hello"}
+	testRunDispatch(t, ctx, dew.NewAction(createPost))
+	if createPost.Result != "post created" {
+		t.Fatalf("unexpected result: %s", createPost.Result)
+	}
+}
+
 func TestMux_GroupsQuery(t *testing.T) {
 	mux := dew.New()
 	mux.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
@@ -659,6 +1806,97 @@ func TestMux_Validation(t *testing.T) {
 	}
 }
 
+func TestDispatchMultiCollect(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	actions := []*createUser{{Name: "john"}, {}, {Name: "jane"}}
+	post := &createPost{}
+	err := dew.DispatchMultiCollect(ctx,
+		dew.NewAction(actions[0]),
+		dew.NewAction(actions[1]),
+		dew.NewAction(post),
+		dew.NewAction(actions[2]),
+	)
+	if err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+
+	// Every valid action is executed.
+	if actions[0].Result != "user created" || actions[2].Result != "user created" {
+		t.Fatalf("unexpected results: %q, %q", actions[0].Result, actions[2].Result)
+	}
+
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got: %v", err)
+	}
+	var handlerErr, validationErr *dew.CommandError
+	if !errors.As(errs[0], &handlerErr) || handlerErr.Index != 1 || !errors.Is(handlerErr, errNameRequired) {
+		t.Fatalf("unexpected handler error: %v", errs[0])
+	}
+	if !errors.As(errs[1], &validationErr) || validationErr.Index != 2 || !errors.Is(validationErr, dew.ErrValidationFailed) {
+		t.Fatalf("unexpected validation error: %v", errs[1])
+	}
+	if validationErr.Type.Name() != "createPost" {
+		t.Fatalf("unexpected command type: %v", validationErr.Type)
+	}
+
+	if err := dew.DispatchMultiCollect(ctx, dew.NewAction(&createUser{Name: "john"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDispatchCollect(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	results, err := dew.DispatchCollect(ctx,
+		dew.NewAction(&createUser{Name: "john"}),
+		dew.NewAction(&createPost{Title: "hello"}),
+		dew.NewAction(&createUser{Name: "jane"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	if u, ok := results[0].(*createUser); !ok || u.Name != "john" || u.Result != "user created" {
+		t.Fatalf("unexpected result: %+v", results[0])
+	}
+	if p, ok := results[1].(*createPost); !ok || p.Result != "post created" {
+		t.Fatalf("unexpected result: %+v", results[1])
+	}
+	if u, ok := results[2].(*createUser); !ok || u.Name != "jane" || u.Result != "user created" {
+		t.Fatalf("unexpected result: %+v", results[2])
+	}
+
+	results, err = dew.DispatchCollect(ctx, dew.NewAction(&createUser{Name: "john"}), dew.NewAction(&createPost{}))
+	if !errors.Is(err, dew.ErrValidationFailed) || results != nil {
+		t.Fatalf("unexpected results: %v, %v", results, err)
+	}
+}
+
+func TestMux_DispatchMultiNoValidate(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(postHandler))
+
+	ctx := dew.NewContext(context.Background(), mux)
+
+	createPost := &createPost{Title: ""}
+	if err := dew.DispatchMultiNoValidate(ctx, dew.NewAction(createPost)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if createPost.Result != "post created" {
+		t.Fatalf("unexpected result: %s", createPost.Result)
+	}
+}
+
 func TestMux_BusContext(t *testing.T) {
 	mux := dew.New()
 
@@ -703,6 +1941,64 @@ func TestMux_BusContext(t *testing.T) {
 	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
 }
 
+func TestMux_DispatchMultiMixedBatch(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Group(func(mux dew.Bus) {
+		mux.Use(dew.ACTION, func(next dew.Middleware) dew.Middleware {
+			return dew.MiddlewareFunc(func(ctx dew.Context) error {
+				return next.Handle(ctx.WithValue(ctxKey{"group"}, "[post]"))
+			})
+		})
+		mux.Register(dew.HandlerFunc[createPost](
+			func(ctx context.Context, command *createPost) error {
+				command.Result = ctx.Value(ctxKey{"group"}).(string) + command.Title
+				return nil
+			},
+		))
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	users := []*createUser{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	posts := []*createPost{{Title: "x"}, {Title: "y"}}
+
+	testRunDispatch(t, ctx,
+		dew.NewAction(users[0]),
+		dew.NewAction(posts[0]),
+		dew.NewAction(users[1]),
+		dew.NewAction(posts[1]),
+		dew.NewAction(users[2]),
+	)
+
+	for _, u := range users {
+		if u.Result != "user created" {
+			t.Fatalf("unexpected result: %s", u.Result)
+		}
+	}
+	for _, p := range posts {
+		if p.Result != "[post]"+p.Title {
+			t.Fatalf("unexpected result: %s", p.Result)
+		}
+	}
+
+	// an unregistered type in a mixed batch still fails to resolve
+	err := dew.DispatchMulti(ctx,
+		dew.NewAction(&createUser{Name: "d"}),
+		dew.NewAction(&updateUser{}),
+	)
+	if !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a nil action does not reuse the handler of an action of the same type
+	err = dew.DispatchMulti(ctx,
+		dew.NewAction(&createUser{Name: "e"}),
+		dew.NewAction[createUser](nil),
+	)
+	if !errors.Is(err, dew.ErrNilCommand) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func BenchmarkMux(b *testing.B) {
 
 	mux1 := dew.New()
@@ -730,6 +2026,20 @@ func BenchmarkMux(b *testing.B) {
 		}
 	})
 
+	b.Run("dispatch-batch-1000", func(b *testing.B) {
+		actions := make([]dew.CommandHandler[dew.Action], 1000)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			for j := range actions {
+				actions[j] = dew.NewAction(&createPost{Title: "john"})
+			}
+			_ = dew.DispatchMulti(ctx1, actions...)
+		}
+	})
+
 	mux2 := dew.New()
 	mux2.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
 		return dew.MiddlewareFunc(func(ctx dew.Context) error {
@@ -0,0 +1,27 @@
+package dew
+
+import "reflect"
+
+// RegisterNormalizer adds a normalizer for commands of type T, e.g. trimming whitespace
+// on string fields. Normalizers are called in the order they were added, before the
+// action is validated, or after the UseQuery middlewares and before the command
+// middlewares and handler of the query run.
+func RegisterNormalizer[T Command](bus Bus, fn func(cmd *T)) {
+	bus.(*mux).handlers.updateOptions(typeFor[T](), func(o *commandOptions) {
+		o.normalizers = append(o.normalizers[:len(o.normalizers):len(o.normalizers)], func(cmd Command) {
+			fn(cmd.(*T))
+		})
+	})
+}
+
+// normalize calls the normalizers registered for the command type.
+func (r *registry) normalize(t reflect.Type, cmd Command) {
+	r.optionsFor(t).normalize(cmd)
+}
+
+// normalize calls the normalizers of the command type.
+func (o *commandOptions) normalize(cmd Command) {
+	for _, fn := range o.normalizers {
+		fn(cmd)
+	}
+}
@@ -0,0 +1,51 @@
+package dew_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestRegisterNormalizer(t *testing.T) {
+	var names []string
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[createUser](func(ctx context.Context, cmd *createUser) error {
+		names = append(names, cmd.Name)
+		return nil
+	}))
+	mux.Register(new(postHandler))
+	mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+		if query.ID != 1 {
+			return errUserNotFound
+		}
+		return nil
+	}))
+	dew.RegisterNormalizer(mux, func(cmd *createUser) {
+		cmd.Name = strings.TrimSpace(cmd.Name)
+	})
+	dew.RegisterNormalizer(mux, func(cmd *createPost) {
+		cmd.Title = strings.TrimSpace(cmd.Title)
+	})
+	dew.RegisterNormalizer(mux, func(query *findUser) {
+		if query.ID < 1 {
+			query.ID = 1
+		}
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "  john \n"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 1 || names[0] != "john" {
+		t.Fatalf("unexpected names: %q", names)
+	}
+
+	// Normalizers run before validation.
+	if _, err := dew.Dispatch(ctx, &createPost{Title: "   "}); err == nil {
+		t.Fatal("expected a validation error, but got nil")
+	}
+
+	testRunQuery(t, ctx, &findUser{ID: -1})
+}
@@ -0,0 +1,80 @@
+package dew
+
+import "time"
+
+// DispatchEvent describes the execution of a command, reported to the observers added with OnDispatch.
+type DispatchEvent struct {
+	// Command is the executed command.
+	Command Command
+	// Op is the operation type of the command.
+	Op OpType
+	// Duration is the time taken by the middlewares and the handler of the command.
+	Duration time.Duration
+	// Err is the error returned by the middlewares or the handler, if any.
+	Err error
+}
+
+// observers holds the observers added with OnDispatch and OnError.
+// It is replaced as a whole when an observer is added, so dispatches read it without locking.
+type observers struct {
+	onDispatch []func(info DispatchEvent)
+	onError    []func(cmd Command, err error)
+}
+
+// OnDispatch adds an observer called after each execution of a command on the bus or any of its groups,
+// including nested commands and the commands of QueryAsync and DispatchAsync.
+// Unlike middlewares, observers cannot alter the execution.
+func (mx *mux) OnDispatch(fn func(info DispatchEvent)) {
+	r := mx.handlers
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.cloneObservers()
+	o.onDispatch = append(o.onDispatch, fn)
+	r.observers.Store(o)
+}
+
+// OnError adds an observer called after each execution of a command that failed,
+// on the bus or any of its groups. It is also called for the commands failing before
+// their execution, when they cannot be resolved, e.g. without a handler, or are invalid.
+func (mx *mux) OnError(fn func(cmd Command, err error)) {
+	r := mx.handlers
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.cloneObservers()
+	o.onError = append(o.onError, fn)
+	r.observers.Store(o)
+}
+
+// cloneObservers returns a copy of the observers. r.mu must be held.
+func (r *registry) cloneObservers() *observers {
+	o := &observers{}
+	if prev := r.observers.Load(); prev != nil {
+		o.onDispatch = append(o.onDispatch, prev.onDispatch...)
+		o.onError = append(o.onError, prev.onError...)
+	}
+	return o
+}
+
+// observe calls the observers with the execution of the command started at start.
+func (o *observers) observe(cmd Command, op OpType, start time.Time, err error) {
+	if len(o.onDispatch) > 0 {
+		info := DispatchEvent{Command: cmd, Op: op, Duration: time.Since(start), Err: err}
+		for _, fn := range o.onDispatch {
+			fn(info)
+		}
+	}
+	if err != nil {
+		for _, fn := range o.onError {
+			fn(cmd, err)
+		}
+	}
+}
+
+// reportError calls the error observers for a command that failed before its execution.
+func (r *registry) reportError(cmd Command, err error) {
+	if o := r.observers.Load(); o != nil {
+		for _, fn := range o.onError {
+			fn(cmd, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestOnDispatch(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+
+	var mu sync.Mutex
+	var events []dew.DispatchEvent
+	var failed []dew.Command
+	mux.OnDispatch(func(info dew.DispatchEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, info)
+	})
+	mux.OnError(func(cmd dew.Command, err error) {
+		if !errors.Is(err, errUserNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, cmd)
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	create := &createUser{Name: "john"}
+	testRunDispatch(t, ctx, dew.NewAction(create))
+	find := &findUser{ID: 1}
+	testRunQuery(t, ctx, find)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Command != create || events[0].Op != dew.ACTION || events[0].Err != nil {
+		t.Fatalf("unexpected event: %+v", events[0])
+	}
+	if events[1].Command != find || events[1].Op != dew.QUERY || events[1].Duration < 0 {
+		t.Fatalf("unexpected event: %+v", events[1])
+	}
+
+	// observers cannot abort the execution, and see the failures
+	notFound := &findUser{ID: 2}
+	if _, err := dew.Query(ctx, notFound); !errors.Is(err, errUserNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failed) != 1 || failed[0] != notFound || events[2].Err == nil {
+		t.Fatalf("unexpected failures: %v", failed)
+	}
+
+	// the commands of QueryAsync are observed
+	if err := dew.QueryAsync(ctx,
+		dew.NewQuery(&findUser{ID: 1}),
+		dew.NewQuery(&findPost{ID: 1}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+}
+
+func TestOnError_BeforeExecution(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(dew.HandlerFunc[orderPlaced](func(ctx context.Context, event *orderPlaced) error {
+		return nil
+	}))
+
+	var failed []error
+	mux.OnError(func(cmd dew.Command, err error) {
+		failed = append(failed, err)
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// handler not found
+	if _, err := dew.Query(ctx, &findPost{ID: 1}); !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// resolution of a nil command
+	if err := dew.DispatchMulti(ctx, dew.NewAction[createUser](nil)); !errors.Is(err, dew.ErrNilCommand) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// validation
+	if _, err := dew.Dispatch(ctx, &orderPlaced{}); !errors.Is(err, dew.ErrValidationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := dew.DispatchAsync(ctx, dew.NewAction(&orderPlaced{})); !errors.Is(err, dew.ErrValidationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// handler not found by the functions executing the handlers directly
+	if err := dew.Publish(ctx, &createPost{Title: "hello"}); !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.QueryResult[findPost, string](ctx, findPost{ID: 1}); !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []error{
+		dew.ErrHandlerNotFound, dew.ErrNilCommand, dew.ErrValidationFailed, dew.ErrValidationFailed,
+		dew.ErrHandlerNotFound, dew.ErrHandlerNotFound,
+	}
+	if len(failed) != len(want) {
+		t.Fatalf("expected %d failures, got %v", len(want), failed)
+	}
+	for i, want := range want {
+		if !errors.Is(failed[i], want) {
+			t.Errorf("unexpected failure #%d: %v", i, failed[i])
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package dew
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrMiddlewareOrder is returned by Verify when a middleware chain violates an ordering constraint.
+var ErrMiddlewareOrder = errors.New("middleware order violated")
+
+// orderConstraint requires the middleware named before to run ahead of the one named after.
+type orderConstraint struct {
+	before, after string
+}
+
+// RequireOrder records that the before middleware must run ahead of the after middleware
+// in every chain containing the after middleware. Constraints are checked by Verify.
+// Middlewares are identified by their function, like in ExportGraphviz.
+func (mx *mux) RequireOrder(before, after func(next Middleware) Middleware) {
+	r := mx.handlers
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = append(r.order, orderConstraint{before: funcName(before), after: funcName(after)})
+}
+
+// Verify checks that the effective middleware chains of the bus and its groups holding
+// registered handlers honor the constraints recorded with RequireOrder, and returns
+// the aggregated violations.
+// The effective chain of an action is made of the dispatch middlewares, the command
+// middlewares, and the handler wrappers; queries use the query middlewares instead.
+// The chain of a command type with middlewares added with UseFor is checked on its own,
+// with those middlewares between the command middlewares and the handler wrappers.
+// Verify also reports the chains exceeding the limit of WithMaxMiddlewareDepth.
+func (mx *mux) Verify() error {
+	r := mx.handlers
+	r.mu.RLock()
+	constraints := r.order
+	r.mu.RUnlock()
+	var errs []error
+	seen := map[string]bool{}
+	for _, g := range append([]*mux{mx}, mx.groups()...) {
+		g.verify(constraints, seen, &errs)
+	}
+	return errors.Join(errs...)
+}
+
+// namedChain is an effective middleware chain with the name used to report its violations.
+type namedChain struct {
+	name string
+	mws  []middleware
+}
+
+// verify appends the violations of the bus to errs, reporting each violation once.
+func (mx *mux) verify(constraints []orderConstraint, seen map[string]bool, errs *[]error) {
+	mx.lock.RLock()
+	for _, err := range mx.checkDepth() {
+		if !seen[err.Error()] {
+			seen[err.Error()] = true
+			*errs = append(*errs, err)
+		}
+	}
+	chains := []namedChain{
+		{name: ACTION.String(), mws: mx.effectiveChain(ACTION, nil)},
+		{name: QUERY.String(), mws: mx.effectiveChain(QUERY, nil)},
+	}
+	var typed []namedChain
+	for t, mws := range mx.typed {
+		typed = append(typed, namedChain{name: t.String(), mws: mx.effectiveChain(opTypeOf(t), mws)})
+	}
+	mx.lock.RUnlock()
+	sort.Slice(typed, func(i, j int) bool { return typed[i].name < typed[j].name })
+	chains = append(chains, typed...)
+
+	for _, chain := range chains {
+		names := make([]string, len(chain.mws))
+		for i, mw := range chain.mws {
+			names[i] = funcName(mw.fn)
+		}
+		for _, c := range constraints {
+			if err := checkOrder(chain.name, names, c); err != nil && !seen[err.Error()] {
+				seen[err.Error()] = true
+				*errs = append(*errs, err)
+			}
+		}
+	}
+}
+
+// effectiveChain returns the middlewares executed for the operation type, in order,
+// with the typed middlewares of a command type, if any. It must be called with the lock held.
+func (mx *mux) effectiveChain(op OpType, typed []middleware) []middleware {
+	var chain []middleware
+	if op == ACTION {
+		chain = append(chain, mx.middlewares[mDispatch]...)
+	} else {
+		chain = append(chain, mx.middlewares[mQuery]...)
+	}
+	chain = append(chain, filterMiddleware(op, mx.middlewares[mCmd])...)
+	chain = append(chain, typed...)
+	return append(chain, filterMiddleware(op, mx.wrappers)...)
+}
+
+// checkOrder returns an error if the after middleware of the constraint is in the named chain
+// without being preceded by the before middleware.
+func checkOrder(chain string, names []string, c orderConstraint) error {
+	for _, name := range names {
+		switch name {
+		case c.before:
+			return nil
+		case c.after:
+			return fmt.Errorf("%w: %s must precede %s in the %s chain",
+				ErrMiddlewareOrder, c.before, c.after, chain)
+		}
+	}
+	return nil
+}
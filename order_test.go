@@ -0,0 +1,80 @@
+package dew_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func authenticate(next dew.Middleware) dew.Middleware {
+	return dew.MiddlewareFunc(func(ctx dew.Context) error {
+		return next.Handle(ctx)
+	})
+}
+
+func auditLog(next dew.Middleware) dew.Middleware {
+	return dew.MiddlewareFunc(func(ctx dew.Context) error {
+		return next.Handle(ctx)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		mux := dew.New()
+		mux.RequireOrder(authenticate, auditLog)
+		mux.UseDispatch(authenticate)
+		mux.Use(dew.ALL, passThrough)
+		mux.Use(dew.ACTION, auditLog)
+		mux.Group(func(mux dew.Bus) {
+			mux.Use(dew.ALL, passThrough)
+		})
+		if err := mux.Verify(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Violation", func(t *testing.T) {
+		mux := dew.New()
+		mux.RequireOrder(authenticate, auditLog)
+		mux.Use(dew.ALL, auditLog)
+		mux.Use(dew.ACTION, authenticate)
+		err := mux.Verify()
+		if !errors.Is(err, dew.ErrMiddlewareOrder) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, want := range []string{"dew_test.authenticate must precede github.com/go-dew/dew_test.auditLog in the ACTION chain", "in the QUERY chain"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("expected %q in error: %v", want, err)
+			}
+		}
+	})
+
+	t.Run("TypedViolation", func(t *testing.T) {
+		mux := dew.New()
+		mux.RequireOrder(authenticate, auditLog)
+		mux.Use(dew.ALL, passThrough)
+		mux.UseFor(createUser{}, auditLog)
+		mux.UseHandlerWrapper(dew.ALL, authenticate)
+		err := mux.Verify()
+		if !errors.Is(err, dew.ErrMiddlewareOrder) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(err.Error(), "in the dew_test.createUser chain") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("GroupViolation", func(t *testing.T) {
+		mux := dew.New()
+		mux.RequireOrder(authenticate, auditLog)
+		mux.Group(func(mux dew.Bus) {
+			mux.UseQuery(auditLog)
+			mux.Register(new(userHandler))
+		})
+		if err := mux.Verify(); !errors.Is(err, dew.ErrMiddlewareOrder) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
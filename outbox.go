@@ -0,0 +1,152 @@
+package dew
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoOutbox is returned by ToOutbox when the action is not executed with the outbox middleware.
+	ErrNoOutbox = errors.New("no outbox in context")
+)
+
+// OutboxRecord is an event persisted in the outbox.
+type OutboxRecord struct {
+	// ID identifies the record in the store.
+	ID int64
+	// Event is the staged event.
+	Event any
+}
+
+// OutboxStore persists outbox events.
+// Implementations should write within the transaction carried by the context,
+// so events are committed atomically with the state change.
+type OutboxStore interface {
+	// Append persists the events staged by an action.
+	Append(ctx context.Context, events []any) error
+	// Pending returns up to limit records that have not been published yet, oldest first.
+	Pending(ctx context.Context, limit int) ([]OutboxRecord, error)
+	// MarkPublished marks the records as published.
+	MarkPublished(ctx context.Context, ids []int64) error
+}
+
+type outboxKey struct{}
+
+// DefaultOutboxInterval is the interval of OutboxDispatcher.Run when the given one is not positive.
+const DefaultOutboxInterval = time.Second
+
+// outboxBuffer holds the events staged by an action.
+// Events may be staged concurrently, e.g. by commands executed with DispatchAsync.
+type outboxBuffer struct {
+	mu     sync.Mutex
+	events []any
+}
+
+// Outbox stages events raised by actions and persists them when the action succeeds.
+type Outbox struct {
+	store OutboxStore
+}
+
+// NewOutbox creates an Outbox persisting events to the store.
+func NewOutbox(store OutboxStore) *Outbox {
+	return &Outbox{store: store}
+}
+
+// Middleware returns the action middleware staging the events raised with ToOutbox.
+// The events are appended to the store only if the handler succeeds.
+// Register it inside the transaction middleware so the events are committed
+// with the transaction and discarded on rollback:
+//
+//	bus.Use(dew.ACTION, Transaction, outbox.Middleware)
+func (o *Outbox) Middleware(next Middleware) Middleware {
+	return MiddlewareFunc(func(ctx Context) error {
+		buf := &outboxBuffer{}
+		if err := next.Handle(ctx.WithValue(outboxKey{}, buf)); err != nil {
+			return err
+		}
+		buf.mu.Lock()
+		events := buf.events
+		buf.mu.Unlock()
+		if len(events) == 0 {
+			return nil
+		}
+		return o.store.Append(ctx.Context(), events)
+	})
+}
+
+// ToOutbox stages the event to be persisted with the current action.
+func ToOutbox(ctx context.Context, event any) error {
+	buf, ok := ctx.Value(outboxKey{}).(*outboxBuffer)
+	if !ok {
+		return ErrNoOutbox
+	}
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	buf.events = append(buf.events, event)
+	return nil
+}
+
+// OutboxDispatcher publishes the pending outbox records.
+// Records are marked as published only after they have been published successfully,
+// so each event is delivered at least once.
+type OutboxDispatcher struct {
+	store     OutboxStore
+	publish   func(ctx context.Context, record OutboxRecord) error
+	batchSize int
+}
+
+// NewOutboxDispatcher creates a dispatcher publishing up to batchSize records at a time.
+func NewOutboxDispatcher(store OutboxStore, publish func(ctx context.Context, record OutboxRecord) error, batchSize int) *OutboxDispatcher {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &OutboxDispatcher{store: store, publish: publish, batchSize: batchSize}
+}
+
+// DispatchPending publishes a batch of pending records and returns the number published.
+// It stops at the first publishing error; the remaining records stay pending.
+func (d *OutboxDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	records, err := d.store.Pending(ctx, d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		ids        []int64
+		publishErr error
+	)
+	for _, r := range records {
+		if publishErr = d.publish(ctx, r); publishErr != nil {
+			break
+		}
+		ids = append(ids, r.ID)
+	}
+	if len(ids) > 0 {
+		if err := d.store.MarkPublished(ctx, ids); err != nil {
+			return 0, errors.Join(publishErr, err)
+		}
+	}
+	return len(ids), publishErr
+}
+
+// Run publishes pending records every interval until the context is cancelled.
+// If interval is not positive, DefaultOutboxInterval is used.
+// The interval is measured with the clock of the bus in the context, if any (see WithClock).
+// Publishing errors are passed to onError if not nil.
+func (d *OutboxDispatcher) Run(ctx context.Context, interval time.Duration, onError func(err error)) {
+	if interval <= 0 {
+		interval = DefaultOutboxInterval
+	}
+	clock := clockFrom(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(interval):
+			if _, err := d.DispatchPending(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
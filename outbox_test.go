@@ -0,0 +1,205 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+// fakeOutboxStore is an in-memory dew.OutboxStore with transactions.
+type fakeOutboxStore struct {
+	mu        sync.Mutex
+	records   []dew.OutboxRecord
+	published map[int64]bool
+	staged    []any
+}
+
+func (s *fakeOutboxStore) Append(_ context.Context, events []any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.staged = append(s.staged, events...)
+	return nil
+}
+
+func (s *fakeOutboxStore) commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.staged {
+		s.records = append(s.records, dew.OutboxRecord{ID: int64(len(s.records) + 1), Event: e})
+	}
+	s.staged = nil
+}
+
+func (s *fakeOutboxStore) rollback() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.staged = nil
+}
+
+func (s *fakeOutboxStore) Pending(_ context.Context, limit int) ([]dew.OutboxRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []dew.OutboxRecord
+	for _, r := range s.records {
+		if !s.published[r.ID] && len(pending) < limit {
+			pending = append(pending, r)
+		}
+	}
+	return pending, nil
+}
+
+func (s *fakeOutboxStore) MarkPublished(_ context.Context, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.published[id] = true
+	}
+	return nil
+}
+
+type userCreated struct {
+	Name string
+}
+
+func TestOutbox(t *testing.T) {
+	store := &fakeOutboxStore{published: make(map[int64]bool)}
+	transaction := func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			if err := next.Handle(ctx); err != nil {
+				store.rollback()
+				return err
+			}
+			store.commit()
+			return nil
+		})
+	}
+
+	mux := dew.New()
+	mux.Use(dew.ACTION, transaction, dew.NewOutbox(store).Middleware)
+	mux.Register(dew.HandlerFunc[createUser](
+		func(ctx context.Context, command *createUser) error {
+			if err := dew.ToOutbox(ctx, userCreated{Name: command.Name}); err != nil {
+				return err
+			}
+			if command.Name == "" {
+				return errNameRequired
+			}
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+	if _, err := dew.Dispatch(ctx, &createUser{}); !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "jane"}))
+
+	if len(store.records) != 2 {
+		t.Fatalf("unexpected records: %v", store.records)
+	}
+
+	// the first publish fails, so the events are delivered at least once
+	var published []string
+	fail := true
+	dispatcher := dew.NewOutboxDispatcher(store, func(_ context.Context, r dew.OutboxRecord) error {
+		if fail && r.ID == 2 {
+			fail = false
+			return errors.New("broker unavailable")
+		}
+		published = append(published, r.Event.(userCreated).Name)
+		return nil
+	}, 10)
+
+	n, err := dispatcher.DispatchPending(context.Background())
+	if err == nil || n != 1 {
+		t.Fatalf("unexpected result: %d, %v", n, err)
+	}
+	n, err = dispatcher.DispatchPending(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("unexpected result: %d, %v", n, err)
+	}
+	if n, _ := dispatcher.DispatchPending(context.Background()); n != 0 {
+		t.Fatalf("expected nothing pending, got: %d", n)
+	}
+	if len(published) != 2 || published[0] != "john" || published[1] != "jane" {
+		t.Fatalf("unexpected published events: %v", published)
+	}
+
+	if err := dew.ToOutbox(context.Background(), userCreated{}); !errors.Is(err, dew.ErrNoOutbox) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOutbox_Concurrent(t *testing.T) {
+	store := &fakeOutboxStore{published: make(map[int64]bool)}
+	mux := dew.New()
+	mux.Use(dew.ACTION, dew.NewOutbox(store).Middleware)
+	mux.Register(dew.HandlerFunc[createUser](
+		func(ctx context.Context, command *createUser) error {
+			var wg sync.WaitGroup
+			errs := make(chan error, 10)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- dew.ToOutbox(ctx, userCreated{Name: command.Name})
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+
+	if len(store.staged) != 10 {
+		t.Fatalf("expected 10 staged events, got: %d", len(store.staged))
+	}
+}
+
+func TestOutboxDispatcher_Run(t *testing.T) {
+	store := &fakeOutboxStore{published: make(map[int64]bool)}
+	store.records = []dew.OutboxRecord{{ID: 1, Event: userCreated{Name: "john"}}}
+	published := make(chan string, 1)
+	dispatcher := dew.NewOutboxDispatcher(store, func(_ context.Context, r dew.OutboxRecord) error {
+		published <- r.Event.(userCreated).Name
+		return nil
+	}, 10)
+
+	clock := new(dewtest.FakeClock)
+	ctx, cancel := context.WithCancel(dew.NewContext(context.Background(), dew.New(dew.WithClock(clock))))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// A non-positive interval falls back to the default one.
+		dispatcher.Run(ctx, 0, nil)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(dew.DefaultOutboxInterval - time.Millisecond)
+	select {
+	case name := <-published:
+		t.Fatalf("unexpected publish before the interval: %s", name)
+	default:
+	}
+	clock.Advance(time.Millisecond)
+	if name := <-published; name != "john" {
+		t.Fatalf("unexpected published event: %s", name)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,58 @@
+package dew
+
+import (
+	"context"
+	"reflect"
+)
+
+type overridesKey struct{}
+
+// WithHandlerOverride returns a new context in which commands of type T are handled by fn
+// instead of the registered handler. The override only applies to dispatches using the
+// returned context, including re-entrant ones; the registry is not modified.
+// The middlewares are still executed. If no handler is registered for T, the command is
+// handled by fn on the bus it is dispatched to, with the middlewares of that bus.
+func WithHandlerOverride[T Command](ctx context.Context, fn func(ctx context.Context, command *T) error) context.Context {
+	prev, _ := ctx.Value(overridesKey{}).(map[reflect.Type]any)
+	overrides := make(map[reflect.Type]any, len(prev)+1)
+	for t, h := range prev {
+		overrides[t] = h
+	}
+	overrides[typeFor[T]()] = HandlerFunc[T](fn)
+	return context.WithValue(ctx, overridesKey{}, overrides)
+}
+
+// contextResolver is implemented by commands resolving their handler with the overrides of the context.
+type contextResolver interface {
+	resolveIn(ctx context.Context, bus Bus) error
+}
+
+// resolve resolves the handler of the command on the bus, considering the overrides of the context.
+// A registered handler is replaced by its override when the command is handled.
+// A command failing to resolve is reported to the error observers.
+func resolve[T Command](ctx context.Context, bus Bus, cmd CommandHandler[T]) error {
+	var err error
+	if r, ok := cmd.(contextResolver); ok {
+		err = r.resolveIn(ctx, bus)
+	} else {
+		err = cmd.Resolve(bus)
+	}
+	if err != nil {
+		bus.(*mux).handlers.reportError(cmd.Command(), err)
+	}
+	return err
+}
+
+// overrideFor returns the override of the command type in the context, if any.
+func overrideFor(ctx context.Context, typ reflect.Type) (any, bool) {
+	overrides, _ := ctx.Value(overridesKey{}).(map[reflect.Type]any)
+	h, ok := overrides[typ]
+	return h, ok
+}
+
+// setupOverride prepares the bus to execute an overridden command without a registered handler,
+// as the bus may have no handler yet, and returns it.
+func (mx *mux) setupOverride() *mux {
+	mx.setupHandler()
+	return mx
+}
@@ -0,0 +1,98 @@
+package dew_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestWithHandlerOverride(t *testing.T) {
+	type findUserName struct {
+		ID     int
+		Result string
+	}
+
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(dew.HandlerFunc[findUserName](
+		func(ctx context.Context, query *findUserName) error {
+			user, err := dew.Query(ctx, &findUser{ID: query.ID})
+			if err != nil {
+				return err
+			}
+			query.Result = user.Result
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	overridden := dew.WithHandlerOverride(ctx, func(ctx context.Context, query *findUser) error {
+		query.Result = "stub"
+		return nil
+	})
+
+	// the override applies to the request, including re-entrant queries
+	if result := testRunQuery(t, overridden, &findUser{ID: 1}); result.Result != "stub" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if result := testRunQuery(t, overridden, &findUserName{ID: 1}); result.Result != "stub" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	asyncQuery := &findUser{ID: 1}
+	if err := dew.QueryAsync(overridden, dew.NewQuery(asyncQuery)); err != nil {
+		t.Fatal(err)
+	}
+	if asyncQuery.Result != "stub" {
+		t.Fatalf("unexpected result: %s", asyncQuery.Result)
+	}
+
+	// other requests use the registered handler
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
+
+func TestWithHandlerOverride_Unregistered(t *testing.T) {
+	var unhandled int
+	mux := dew.New()
+	mux.OnUnhandled(func(cmdType reflect.Type, op dew.OpType) { unhandled++ })
+	var executed []string
+	mux.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			executed = append(executed, reflect.TypeOf(ctx.Command()).Elem().Name())
+			return next.Handle(ctx)
+		})
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	ctx = dew.WithHandlerOverride(ctx, func(ctx context.Context, query *findUser) error {
+		query.Result = "stub"
+		return nil
+	})
+	ctx = dew.WithHandlerOverride(ctx, func(ctx context.Context, action *createUser) error {
+		action.Result = "stub"
+		return nil
+	})
+
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "stub" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	action := &createUser{Name: "john"}
+	if err := dew.DispatchMulti(ctx, dew.NewAction(action), dew.NewAction(&createUser{Name: "jane"})); err != nil {
+		t.Fatal(err)
+	}
+	if action.Result != "stub" {
+		t.Fatalf("unexpected result: %s", action.Result)
+	}
+	if err := dew.DispatchAny(ctx, &createUser{Name: "jack"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(executed) != 4 {
+		t.Fatalf("expected the middlewares to run for the overridden commands, got: %v", executed)
+	}
+	if unhandled != 0 {
+		t.Fatalf("unexpected unhandled notifications: %d", unhandled)
+	}
+}
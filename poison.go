@@ -0,0 +1,45 @@
+package dew
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrContextReleased is the panic value raised when a Context is used after the
+// command execution it was created for has returned, with WithContextPoisoning enabled.
+var ErrContextReleased = errors.New("dew: context used after release")
+
+// WithContextPoisoning makes the bus poison each Context when its execution returns,
+// instead of putting it back into the pool. Any later use of the Context, e.g. by a
+// middleware that captured it, panics with ErrContextReleased.
+// It is meant for tests: as contexts are not reused, it disables the context pooling.
+func WithContextPoisoning() Option {
+	return func(mx *mux) {
+		mx.poison = true
+	}
+}
+
+// release returns the context to the pool, or poisons it if poisoning is enabled.
+func (mx *mux) release(c *BusContext) {
+	if mx.poison {
+		c.Reset()
+		c.ctx = poisonedContext{}
+		c.handler = poisonedHandler{}
+		return
+	}
+	mx.pool.Put(c)
+}
+
+// poisonedContext is the context.Context of a released Context.
+type poisonedContext struct{}
+
+func (poisonedContext) Deadline() (time.Time, bool) { panic(ErrContextReleased) }
+func (poisonedContext) Done() <-chan struct{}       { panic(ErrContextReleased) }
+func (poisonedContext) Err() error                  { panic(ErrContextReleased) }
+func (poisonedContext) Value(any) any               { panic(ErrContextReleased) }
+
+// poisonedHandler is the handler of a released Context.
+type poisonedHandler struct{}
+
+func (poisonedHandler) Handle(Context) error { panic(ErrContextReleased) }
+func (poisonedHandler) Command() Command     { panic(ErrContextReleased) }
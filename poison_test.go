@@ -0,0 +1,70 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestWithContextPoisoning(t *testing.T) {
+	var leaked dew.Context
+	leak := func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			leaked = ctx
+			return next.Handle(ctx)
+		})
+	}
+
+	mux := dew.New(dew.WithContextPoisoning())
+	mux.Use(dew.ALL, leak)
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+
+	for name, use := range map[string]func(){
+		"Command": func() { leaked.Command() },
+		"Context": func() { leaked.Context().Value(ctxKey{"name"}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if err, ok := r.(error); !ok || !errors.Is(err, dew.ErrContextReleased) {
+					t.Fatalf("expected a panic with ErrContextReleased, got: %v", r)
+				}
+			}()
+			use()
+		})
+	}
+}
+
+func TestWithContextPoisoning_CleanGroup(t *testing.T) {
+	var leaked dew.Context
+	mux := dew.New(dew.WithContextPoisoning())
+	clean := mux.CleanGroup(func(mx dew.Bus) {
+		mx.Use(dew.ALL, func(next dew.Middleware) dew.Middleware {
+			return dew.MiddlewareFunc(func(ctx dew.Context) error {
+				leaked = ctx
+				return next.Handle(ctx)
+			})
+		})
+		mx.Register(new(userHandler))
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, err := dew.QueryTo(ctx, clean, &findUser{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || !errors.Is(err, dew.ErrContextReleased) {
+			t.Fatalf("expected a panic with ErrContextReleased, got: %v", r)
+		}
+	}()
+	leaked.Command()
+}
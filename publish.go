@@ -0,0 +1,70 @@
+package dew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Publish executes every handler registered for the action type, in registration order,
+// e.g. the handlers reacting to a domain event. Unlike Dispatch, which executes the last
+// registered handler only, it allows several independent handlers to react to one action:
+//
+//	bus.Register(new(InventoryHandler)) // handles *OrderPlaced
+//	bus.Register(new(EmailHandler))     // handles *OrderPlaced
+//	err := dew.Publish(ctx, &OrderPlaced{ID: id})
+//
+// The event is validated once, then every handler is executed with it, even if some fail.
+// Dispatch middlewares are executed once, command middlewares once per handler.
+// The errors of the handlers are joined with errors.Join.
+func Publish[T Action](ctx context.Context, event *T) error {
+	bus, ok := FromContext(ctx)
+	if !ok {
+		return ErrBusNotInContext
+	}
+
+	typ := typeFor[T]()
+	if event == nil {
+		return fmt.Errorf("%w: %v", ErrNilCommand, typ)
+	}
+	mux := bus.(*mux)
+	e, ok := mux.handlers.load().entriesFor(ACTION).Load(typ)
+	if !ok {
+		mux.handlers.notifyUnhandled(typ, ACTION)
+		err := fmt.Errorf("%w for %v", ErrHandlerNotFound, typ)
+		mux.handlers.reportError(event, err)
+		return err
+	}
+	all := e.(*handler).all
+
+	if err := mux.begin(ctx); err != nil {
+		return err
+	}
+	defer mux.end()
+
+	rctx := mux.newContext(ctx)
+
+	defer mux.release(rctx)
+
+	return mux.mHandlers[mDispatch](rctx, func(ctx Context) error {
+		mux.handlers.normalize(typ, event)
+		if err := validateAction(ctx.Context(), any(event).(Action)); err != nil {
+			mux.handlers.reportError(event, err)
+			return err
+		}
+		var errs []error
+		for _, h := range all {
+			c := &command[T]{
+				cmd:     event,
+				typ:     typ,
+				op:      ACTION,
+				handler: convertInterface[HandlerFunc[T]](h.handler),
+				mux:     h.mux,
+			}
+			if err := h.mux.dispatch(ACTION, ctx, c); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
@@ -0,0 +1,88 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type orderPlaced struct {
+	ID int
+}
+
+func (e orderPlaced) Validate(_ context.Context) error {
+	if e.ID == 0 {
+		return errors.New("missing order")
+	}
+	return nil
+}
+
+func TestPublish(t *testing.T) {
+	errMailDown := errors.New("mail server down")
+	var calls []string
+	observer := func(name string, err error) dew.HandlerFunc[orderPlaced] {
+		return func(ctx context.Context, event *orderPlaced) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	mux := dew.New()
+	mux.Register(observer("inventory", nil))
+	mux.Group(func(mux dew.Bus) {
+		mux.Register(observer("email", nil))
+	})
+	mux.Register(observer("analytics", nil))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if err := dew.Publish(ctx, &orderPlaced{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "inventory,email,analytics" {
+		t.Fatalf("unexpected calls: %s", got)
+	}
+
+	// Dispatch keeps executing the last registered handler only
+	calls = nil
+	testRunDispatch(t, ctx, dew.NewAction(&orderPlaced{ID: 1}))
+	if got := strings.Join(calls, ","); got != "analytics" {
+		t.Fatalf("unexpected calls: %s", got)
+	}
+
+	// all handlers are executed even if some fail
+	calls = nil
+	mux.Register(observer("mail", errMailDown))
+	mux.Register(observer("audit", nil))
+	if err := dew.Publish(ctx, &orderPlaced{ID: 1}); !errors.Is(err, errMailDown) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "inventory,email,analytics,mail,audit" {
+		t.Fatalf("unexpected calls: %s", got)
+	}
+
+	// Dispatch executes the handler registered after the action was dispatched
+	calls = nil
+	testRunDispatch(t, ctx, dew.NewAction(&orderPlaced{ID: 1}))
+	if got := strings.Join(calls, ","); got != "audit" {
+		t.Fatalf("unexpected calls: %s", got)
+	}
+
+	// invalid events are not published
+	calls = nil
+	if err := dew.Publish(ctx, &orderPlaced{}); !errors.Is(err, dew.ErrValidationFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestPublish_HandlerNotFound(t *testing.T) {
+	ctx := dew.NewContext(context.Background(), dew.New())
+	if err := dew.Publish(ctx, &orderPlaced{ID: 1}); !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
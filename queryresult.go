@@ -0,0 +1,77 @@
+package dew
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// QueryResult executes the query with a handler returning its result, instead of writing it
+// into the query, and returns the result:
+//
+//	dew.RegisterResult(bus, func(ctx context.Context, query *FindUser) (*User, error) { ... })
+//
+//	user, err := dew.QueryResult[FindUser, *User](ctx, FindUser{ID: 1})
+//
+// It returns an error wrapping ErrHandlerNotFound if the handler of the query was not
+// registered with RegisterResult, or does not return a result of type R.
+func QueryResult[Q QueryAction, R any](ctx context.Context, query Q) (R, error) {
+	var result R
+	bus, ok := FromContext(ctx)
+	if !ok {
+		return result, ErrBusNotInContext
+	}
+
+	mux := bus.(*mux)
+	typ := typeFor[Q]()
+	h, ok := mux.handlers.load().lookup(QUERY, typ)
+	if !ok {
+		mux.handlers.notifyUnhandled(typ, QUERY)
+		err := fmt.Errorf("%w for %v", ErrHandlerNotFound, typ)
+		mux.handlers.reportError(&query, err)
+		return result, err
+	}
+	fn, ok := h.result.(func(context.Context, *Q) (R, error))
+	if !ok {
+		return result, fmt.Errorf("%w for %v returning %v", ErrHandlerNotFound, typ, reflect.TypeOf(&result).Elem())
+	}
+
+	queryObj := &command[Q]{
+		cmd: &query,
+		typ: typ,
+		op:  QUERY,
+		mux: h.mux,
+		handler: func(ctx context.Context, query *Q) (err error) {
+			result, err = fn(ctx, query)
+			return err
+		},
+	}
+	if err := runQuery[Q](mux, ctx, queryObj); err != nil {
+		var zero R
+		return zero, err
+	}
+	return result, nil
+}
+
+// RegisterResult adds the handler function returning the result of the query Q to the bus,
+// for QueryResult. Query can still execute it, discarding the result. Result handlers are only
+// registered explicitly: Register ignores the methods returning a result, so that a handler
+// struct can have helper methods of that form.
+func RegisterResult[Q QueryAction, R any](bus Bus, fn func(ctx context.Context, query *Q) (R, error)) {
+	mx := bus.(*mux)
+	m := handlerMethod{
+		op:      QUERY,
+		cmdType: typeFor[Q](),
+		fn: func(ctx context.Context, query *Q) error {
+			_, err := fn(ctx, query)
+			return err
+		},
+		result: fn,
+		name:   funcName(fn),
+	}
+	if err := mx.handlers.checkStrict([]handlerMethod{m}); err != nil {
+		panic(err)
+	}
+	mx.addHandler(m)
+	mx.setupHandler()
+}
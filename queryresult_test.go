@@ -0,0 +1,145 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+type getUser struct {
+	ID int
+}
+
+type getUserName struct {
+	ID int
+}
+
+type profileHandler struct{}
+
+func (profileHandler) GetUser(_ context.Context, query *getUser) (*user, error) {
+	if query.ID != 1 {
+		return nil, errUserNotFound
+	}
+	return &user{ID: 1, Name: "john"}, nil
+}
+
+func (profileHandler) GetUserName(_ context.Context, query *getUserName) (string, error) {
+	return "john", nil
+}
+
+func TestQueryResult(t *testing.T) {
+	var queried []string
+	mux := dew.New()
+	mux.Use(dew.QUERY, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			queried = append(queried, fmt.Sprintf("%T", ctx.Command()))
+			return next.Handle(ctx)
+		})
+	})
+	dew.RegisterResult(mux, profileHandler{}.GetUser)
+	dew.RegisterResult(mux, profileHandler{}.GetUserName)
+	mux.Register(new(userHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	u, err := dew.QueryResult[getUser, *user](ctx, getUser{ID: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.Name != "john" {
+		t.Fatalf("unexpected result: %+v", u)
+	}
+	if name, err := dew.QueryResult[getUserName, string](ctx, getUserName{ID: 1}); err != nil || name != "john" {
+		t.Fatalf("unexpected result: %q, %v", name, err)
+	}
+	// the middlewares are executed
+	if len(queried) != 2 || queried[0] != "*dew_test.getUser" {
+		t.Fatalf("unexpected queries: %v", queried)
+	}
+
+	if _, err := dew.QueryResult[getUser, *user](ctx, getUser{ID: 2}); !errors.Is(err, errUserNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the handlers can still be executed with Query, and side by side with the other style
+	if _, err := dew.Query(ctx, &getUser{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+
+	// the handler must return a result of the requested type
+	if _, err := dew.QueryResult[getUser, string](ctx, getUser{ID: 1}); !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.QueryResult[findUser, string](ctx, findUser{ID: 1}); !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type userReportHandler struct{}
+
+func (userReportHandler) FindUser(_ context.Context, query *findUser) error {
+	query.Result = "john"
+	return nil
+}
+
+func (userReportHandler) FindUserRaw(_ context.Context, query *findUser) ([]byte, error) {
+	return []byte("raw"), nil
+}
+
+type twiceUserHandler struct{}
+
+func (twiceUserHandler) FindUser(_ context.Context, query *findUser) error { return nil }
+
+func (twiceUserHandler) FindUserAgain(_ context.Context, query *findUser) error { return nil }
+
+type renameUser struct {
+	Name string
+}
+
+func (renameUser) Validate(context.Context) error { return nil }
+
+type actionResultHandler struct{}
+
+func (actionResultHandler) RenameUser(_ context.Context, action *renameUser) (*user, error) {
+	return &user{Name: action.Name}, nil
+}
+
+func TestQueryResult_HandlerKinds(t *testing.T) {
+	mux := dew.New()
+	mux.Register(userReportHandler{})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// methods returning a result are not registered by Register
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if _, err := dew.QueryResult[findUser, []byte](ctx, findUser{ID: 1}); !errors.Is(err, dew.ErrHandlerNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mux.RegisterChecked(actionResultHandler{}); !errors.Is(err, dew.ErrNoHandlerMethods) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a handler cannot handle a command type twice
+	if err := mux.RegisterChecked(twiceUserHandler{}); !errors.Is(err, dew.ErrDuplicateHandler) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, dew.ErrDuplicateHandler) {
+				t.Fatalf("unexpected panic: %v", err)
+			}
+		}()
+		dew.New().Register(twiceUserHandler{})
+	}()
+}
@@ -0,0 +1,42 @@
+package dew
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+)
+
+// PanicError is returned by the Recoverer middleware when a middleware or handler panics.
+type PanicError struct {
+	// Type is the type of the command being executed.
+	Type reflect.Type
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %v: %v", e.Type, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recoverer is a middleware that recovers from panics in the rest of the chain
+// and returns them as a PanicError, including the command type and the stack trace.
+// As command middlewares run in the goroutine executing the command, it also protects
+// the queries executed by QueryAsync.
+func Recoverer(next Middleware) Middleware {
+	return MiddlewareFunc(func(ctx Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Type: reflect.TypeOf(ctx.Command()), Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return next.Handle(ctx)
+	})
+}
@@ -0,0 +1,45 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestRecoverer(t *testing.T) {
+	mux := dew.New()
+	mux.Use(dew.ALL, dew.Recoverer)
+	mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+		var m map[int]string
+		m[query.ID] = "john"
+		return nil
+	}))
+	mux.Register(dew.HandlerFunc[findPost](func(ctx context.Context, query *findPost) error {
+		panic(errDenied)
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	_, err := dew.Query(ctx, &findUser{ID: 1})
+	var panicErr *dew.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "panic in *dew_test.findUser: assignment to entry in nil map") {
+		t.Fatalf("unexpected message: %v", err)
+	}
+	if len(panicErr.Stack) == 0 {
+		t.Fatal("expected a stack trace")
+	}
+
+	// Panics in the goroutines of QueryAsync are recovered too.
+	err = dew.QueryAsync(ctx, dew.NewQuery(&findPost{ID: 1}), dew.NewQuery(&findUser{ID: 1}))
+	if !errors.Is(err, errDenied) {
+		t.Fatalf("expected the panic value to be wrapped, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "panic in *dew_test.findPost") || !strings.Contains(err.Error(), "panic in *dew_test.findUser") {
+		t.Fatalf("unexpected message: %v", err)
+	}
+}
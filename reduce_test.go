@@ -0,0 +1,74 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type countItems struct {
+	Count int
+}
+
+func TestQueryReduce(t *testing.T) {
+	mux := dew.New()
+	mux.Group(func(mux dew.Bus) {
+		mux.Register(dew.HandlerFunc[countItems](
+			func(ctx context.Context, query *countItems) error {
+				query.Count = 3
+				return nil
+			},
+		))
+	})
+	mux.Group(func(mux dew.Bus) {
+		mux.Register(dew.HandlerFunc[countItems](
+			func(ctx context.Context, query *countItems) error {
+				query.Count = 4
+				return nil
+			},
+		))
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	query := &countItems{}
+	sum, err := dew.QueryReduce(ctx, query, 0, func(acc int, result *countItems) int {
+		return acc + result.Count
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != 7 {
+		t.Fatalf("unexpected sum: %d", sum)
+	}
+	if query.Count != 0 {
+		t.Fatalf("expected the original query to be untouched, got: %d", query.Count)
+	}
+}
+
+func TestQueryReduce_Error(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[countItems](
+		func(ctx context.Context, query *countItems) error { return errFirst },
+	))
+	mux.Register(dew.HandlerFunc[countItems](
+		func(ctx context.Context, query *countItems) error { return errSecond },
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	_, err := dew.QueryReduce(ctx, &countItems{}, 0, func(acc int, result *countItems) int {
+		return acc + result.Count
+	})
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = dew.QueryReduce(ctx, &findUser{}, 0, func(acc int, result *findUser) int { return acc })
+	if err == nil {
+		t.Fatal("expected handler not found error, got nil")
+	}
+}
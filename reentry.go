@@ -0,0 +1,53 @@
+package dew
+
+import (
+	"context"
+	"reflect"
+)
+
+// reentryPolicy holds the policy set with SetReentryPolicy.
+type reentryPolicy struct {
+	fn func(stack []reflect.Type, next reflect.Type) error
+}
+
+type reentryKey struct{}
+
+// SetReentryPolicy sets the policy consulted before each re-entrant execution, i.e. a command
+// issued by a handler, with the types of the commands being executed, outermost first,
+// and the type of the issued command. If it returns an error, the command is not executed
+// and the error is returned, e.g. to forbid FindUser from issuing FindUserPosts:
+//
+//	bus.SetReentryPolicy(func(stack []reflect.Type, next reflect.Type) error {
+//		if stack[len(stack)-1] == reflect.TypeOf(FindUser{}) && next == reflect.TypeOf(FindUserPosts{}) {
+//			return errors.New("FindUser must not issue FindUserPosts")
+//		}
+//		return nil
+//	})
+//
+// The policy applies to the bus and all its groups. A nil policy allows every execution.
+func (mx *mux) SetReentryPolicy(policy func(stack []reflect.Type, next reflect.Type) error) {
+	if policy == nil {
+		mx.handlers.reentry.Store(nil)
+		return
+	}
+	mx.handlers.reentry.Store(&reentryPolicy{fn: policy})
+}
+
+// enter consults the re-entry policy for the execution of a command of type t with the context,
+// and returns the context of the execution, recording the command on the stack.
+// The stack is only tracked while a policy is set.
+func (r *registry) enter(ctx context.Context, t reflect.Type) (context.Context, error) {
+	policy := r.reentry.Load()
+	if policy == nil {
+		return ctx, nil
+	}
+	stack, _ := ctx.Value(reentryKey{}).([]reflect.Type)
+	if len(stack) > 0 {
+		if err := policy.fn(stack[:len(stack):len(stack)], t); err != nil {
+			return nil, err
+		}
+	}
+	next := make([]reflect.Type, len(stack), len(stack)+1)
+	copy(next, stack)
+	return context.WithValue(ctx, reentryKey{}, append(next, t)), nil
+}
@@ -0,0 +1,77 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestSetReentryPolicy(t *testing.T) {
+	type findUserPost struct {
+		ID int
+	}
+	type findPostAuthor struct {
+		ID int
+	}
+
+	errForbidden := errors.New("forbidden re-entry")
+
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	mux.Register(dew.HandlerFunc[findUserPost](
+		func(ctx context.Context, query *findUserPost) error {
+			if _, err := dew.Query(ctx, &findUser{ID: query.ID}); err != nil {
+				return err
+			}
+			_, err := dew.Query(ctx, &findPostAuthor{ID: query.ID})
+			return err
+		},
+	))
+	mux.Register(dew.HandlerFunc[findPostAuthor](
+		func(ctx context.Context, query *findPostAuthor) error {
+			_, err := dew.Query(ctx, &findPost{ID: query.ID})
+			return err
+		},
+	))
+
+	var stacks [][]reflect.Type
+	mux.SetReentryPolicy(func(stack []reflect.Type, next reflect.Type) error {
+		stacks = append(stacks, stack)
+		if next == reflect.TypeOf(findPost{}) && stack[len(stack)-1] == reflect.TypeOf(findPostAuthor{}) {
+			return errForbidden
+		}
+		return nil
+	})
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// top-level commands are not re-entrant
+	if result := testRunQuery(t, ctx, &findPost{ID: 1}); result.Result != "hello" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+	if len(stacks) != 0 {
+		t.Fatalf("unexpected policy calls: %v", stacks)
+	}
+
+	if _, err := dew.Query(ctx, &findUserPost{ID: 1}); !errors.Is(err, errForbidden) {
+		t.Fatalf("expected forbidden error, got %v", err)
+	}
+	// findUser and findPostAuthor proceeded, findPost was blocked
+	expected := [][]reflect.Type{
+		{reflect.TypeOf(findUserPost{})},
+		{reflect.TypeOf(findUserPost{})},
+		{reflect.TypeOf(findUserPost{}), reflect.TypeOf(findPostAuthor{})},
+	}
+	if !reflect.DeepEqual(stacks, expected) {
+		t.Fatalf("unexpected stacks: %v", stacks)
+	}
+
+	// removing the policy allows every command
+	mux.SetReentryPolicy(nil)
+	if _, err := dew.Query(ctx, &findUserPost{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package dew
+
+import "reflect"
+
+// Resulter is implemented by commands that expose their result,
+// so generic middlewares and callers can read it without reflection.
+type Resulter interface {
+	// GetResult returns the result of the command.
+	GetResult() any
+}
+
+// ResultOf returns the result of the command. It uses GetResult if the command
+// implements Resulter, and falls back to the exported Result field of the command struct.
+// It reports false if the command has neither.
+func ResultOf(cmd Command) (any, bool) {
+	if r, ok := cmd.(Resulter); ok {
+		return r.GetResult(), true
+	}
+	v := reflect.ValueOf(cmd)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	f, ok := v.Type().FieldByName("Result")
+	if !ok || !f.IsExported() {
+		return nil, false
+	}
+	return v.FieldByIndex(f.Index).Interface(), true
+}
@@ -0,0 +1,49 @@
+package dew_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type countUsers struct {
+	count int
+}
+
+func (q *countUsers) GetResult() any { return q.count }
+
+func TestResultOf(t *testing.T) {
+	var results []any
+	mux := dew.New()
+	mux.Use(dew.QUERY, func(next dew.Middleware) dew.Middleware {
+		return dew.MiddlewareFunc(func(ctx dew.Context) error {
+			if err := next.Handle(ctx); err != nil {
+				return err
+			}
+			if result, ok := dew.ResultOf(ctx.Command()); ok {
+				results = append(results, result)
+			}
+			return nil
+		})
+	})
+	mux.Register(new(userHandler))
+	mux.Register(dew.HandlerFunc[countUsers](func(ctx context.Context, query *countUsers) error {
+		query.count = 42
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	testRunQuery(t, ctx, &countUsers{})
+	if len(results) != 2 || results[0] != "john" || results[1] != 42 {
+		t.Fatalf("unexpected results: %v", results)
+	}
+
+	if _, ok := dew.ResultOf(&ctxKey{}); ok {
+		t.Fatal("expected no result for a command without a Result field")
+	}
+	if _, ok := dew.ResultOf((*findUser)(nil)); ok {
+		t.Fatal("expected no result for a nil command")
+	}
+}
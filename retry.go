@@ -0,0 +1,76 @@
+package dew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryAfterError is returned by handlers when the command can be retried after a delay,
+// e.g. when rate-limited or when a resource is temporarily unavailable.
+type RetryAfterError struct {
+	// After is the duration to wait before retrying.
+	After time.Duration
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("retry after %v: %v", e.After, e.Err)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// attemptKey is the context key holding the current attempt number.
+var attemptKey = &contextKey{"Attempt"}
+
+// Attempt returns the current attempt number of the command being executed,
+// starting at 1. It is greater than 1 when the handler is re-run by the Retry middleware.
+func Attempt(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// Retry returns a middleware that re-runs the rest of the chain up to maxAttempts times
+// while it returns an error. If the error is a RetryAfterError, it waits for the requested
+// delay before the next attempt. Context cancellation errors are not retried.
+// If maxAttempts is less than 1, the command is executed once.
+func Retry(maxAttempts int) func(next Middleware) Middleware {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			parent := ctx.Context()
+			defer ctx.WithContext(parent)
+
+			var err error
+			for attempt := 1; attempt <= maxAttempts; attempt++ {
+				ctx.WithContext(context.WithValue(parent, attemptKey, attempt))
+				if err = next.Handle(ctx); err == nil {
+					return nil
+				}
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || attempt == maxAttempts {
+					break
+				}
+				var retryErr *RetryAfterError
+				if errors.As(err, &retryErr) && retryErr.After > 0 {
+					elapsed := make(chan struct{})
+					t := clockFrom(parent).AfterFunc(retryErr.After, func() { close(elapsed) })
+					select {
+					case <-elapsed:
+					case <-parent.Done():
+						t.Stop()
+						return parent.Err()
+					}
+				}
+			}
+			return err
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+	"github.com/go-dew/dew/dewtest"
+)
+
+func TestRetryAfterError(t *testing.T) {
+	errRateLimited := errors.New("rate limited")
+
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			return &dew.RetryAfterError{After: 3 * time.Second, Err: errRateLimited}
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	_, err := dew.Query(ctx, &findUser{ID: 1})
+	var retryErr *dew.RetryAfterError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retryErr.After != 3*time.Second {
+		t.Fatalf("unexpected duration: %v", retryErr.After)
+	}
+	if !errors.Is(err, errRateLimited) {
+		t.Fatalf("expected the wrapped error, got: %v", err)
+	}
+}
+
+func TestRetry_Attempt(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+
+	var attempts []int
+	mux := dew.New()
+	mux.Use(dew.ALL, dew.Retry(3))
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			attempts = append(attempts, dew.Attempt(ctx))
+			if dew.Attempt(ctx) < 3 {
+				return &dew.RetryAfterError{After: time.Millisecond, Err: errUnavailable}
+			}
+			query.Result = "john"
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	query, err := dew.Query(ctx, &findUser{ID: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Result != "john" {
+		t.Fatalf("unexpected result: %s", query.Result)
+	}
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[1] != 2 || attempts[2] != 3 {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+}
+
+func TestRetry_GiveUp(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+
+	calls := 0
+	mux := dew.New()
+	mux.Use(dew.ALL, dew.Retry(2))
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			calls++
+			return errUnavailable
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); !errors.Is(err, errUnavailable) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestAttempt_Default(t *testing.T) {
+	var attempt int
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			attempt = dew.Attempt(ctx)
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempt != 1 {
+		t.Fatalf("expected attempt 1, got %d", attempt)
+	}
+}
+
+func TestRetry_Clock(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+
+	clock := dewtest.NewFakeClock(time.Now())
+	mux := dew.New(dew.WithClock(clock))
+	mux.Use(dew.ALL, dew.Retry(3))
+	var attempts []time.Time
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			attempts = append(attempts, clock.Now())
+			if dew.Attempt(ctx) < 3 {
+				return &dew.RetryAfterError{After: time.Duration(dew.Attempt(ctx)) * time.Hour, Err: errUnavailable}
+			}
+			query.Result = "john"
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := dew.Query(ctx, &findUser{ID: 1})
+		done <- err
+	}()
+
+	// the retry waits for the clock, not for the system time
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour - time.Minute)
+	select {
+	case err := <-done:
+		t.Fatalf("retried before the delay: %v", err)
+	default:
+	}
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Hour)
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attempts) != 3 || attempts[1].Sub(attempts[0]) != time.Hour || attempts[2].Sub(attempts[1]) != 2*time.Hour {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+}
+
+func TestRetry_NoAttempts(t *testing.T) {
+	for _, maxAttempts := range []int{0, -1} {
+		calls := 0
+		mux := dew.New()
+		mux.Use(dew.ALL, dew.Retry(maxAttempts))
+		mux.Register(dew.HandlerFunc[findUser](
+			func(ctx context.Context, query *findUser) error {
+				calls++
+				return errUserNotFound
+			},
+		))
+		ctx := dew.NewContext(context.Background(), mux)
+
+		if _, err := dew.Query(ctx, &findUser{ID: 1}); !errors.Is(err, errUserNotFound) {
+			t.Fatalf("unexpected error with %d attempts: %v", maxAttempts, err)
+		}
+		if calls != 1 {
+			t.Fatalf("expected the handler to be called once with %d attempts, got %d", maxAttempts, calls)
+		}
+	}
+}
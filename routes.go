@@ -0,0 +1,97 @@
+package dew
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Route binds a command type to its handler function explicitly.
+type Route struct {
+	// Command is a value or a pointer of the command type, e.g. CreateUser{} or (*CreateUser)(nil).
+	Command any
+	// Handler is the handler function with the signature func(context.Context, *T) error,
+	// where T is the command type, e.g. a HandlerFunc[T] or a method value.
+	Handler any
+	// Op is the operation type of the command. If zero, it is ACTION for actions and QUERY otherwise.
+	Op OpType
+}
+
+// LoadRoutes registers the handlers of the route table.
+// All routes are checked before any of them is registered: if a route is missing its command
+// or handler, has a handler with the wrong signature, or binds an already handled command type,
+// no handler is registered and the aggregated errors are returned.
+func (mx *mux) LoadRoutes(routes []Route) error {
+	set := mx.handlers.load()
+	methods := make([]handlerMethod, 0, len(routes))
+	seen := make(map[handlerKey]string, len(routes))
+	var errs []error
+	for i, r := range routes {
+		m, err := routeMethod(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("route #%d: %w", i, err))
+			continue
+		}
+		key := handlerKey{op: m.op, t: m.cmdType}
+		if prev, ok := seen[key]; ok {
+			errs = append(errs, fmt.Errorf("route #%d: %w: %s for %v, already handled by %s",
+				i, ErrDuplicateHandler, m.name, m.cmdType, prev))
+			continue
+		}
+		if prev, ok := set.entriesFor(m.op).Load(m.cmdType); ok {
+			errs = append(errs, fmt.Errorf("route #%d: %w: %s for %v, already handled by %s",
+				i, ErrDuplicateHandler, m.name, m.cmdType, prev.(*handler).name))
+			continue
+		}
+		seen[key] = m.name
+		methods = append(methods, m)
+	}
+	if err := mx.handlers.checkStrict(methods); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for _, m := range methods {
+		mx.addHandler(m)
+	}
+	mx.setupHandler()
+	return nil
+}
+
+// routeMethod checks the route and returns the handler method it binds.
+func routeMethod(r Route) (handlerMethod, error) {
+	if r.Command == nil {
+		return handlerMethod{}, errors.New("missing command")
+	}
+	cmdType := reflect.TypeOf(r.Command)
+	if cmdType.Kind() == reflect.Ptr {
+		cmdType = cmdType.Elem()
+	}
+	if r.Handler == nil {
+		return handlerMethod{}, fmt.Errorf("missing handler for %v", cmdType)
+	}
+	op := r.Op
+	switch op {
+	case 0:
+		op = opTypeOf(cmdType)
+	case QUERY:
+	case ACTION:
+		if !cmdType.Implements(actionType) {
+			return handlerMethod{}, fmt.Errorf("%v does not implement Action", cmdType)
+		}
+	default:
+		return handlerMethod{}, fmt.Errorf("invalid operation type %d for %v", r.Op, cmdType)
+	}
+	fnType := reflect.FuncOf([]reflect.Type{ctxType, reflect.PtrTo(cmdType)}, []reflect.Type{errType}, false)
+	fn := reflect.ValueOf(r.Handler)
+	if fn.Kind() != reflect.Func || !fn.Type().ConvertibleTo(fnType) {
+		return handlerMethod{}, fmt.Errorf("handler %T for %v must be a %v", r.Handler, cmdType, fnType)
+	}
+	return handlerMethod{
+		op:      op,
+		cmdType: cmdType,
+		fn:      fn.Convert(fnType).Interface(),
+		name:    funcName(r.Handler),
+	}, nil
+}
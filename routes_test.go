@@ -0,0 +1,109 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestLoadRoutes(t *testing.T) {
+	h := new(userHandler)
+	mux := dew.New()
+	err := mux.LoadRoutes([]dew.Route{
+		{Command: createUser{}, Handler: h.CreateUser, Op: dew.ACTION},
+		{Command: (*findUser)(nil), Handler: h.FindUser, Op: dew.QUERY},
+		{Command: createPost{}, Handler: dew.HandlerFunc[createPost](func(ctx context.Context, cmd *createPost) error {
+			cmd.Result = "routed"
+			return nil
+		})},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := dew.NewContext(context.Background(), mux)
+
+	action := &createUser{Name: "john"}
+	post := &createPost{Title: "hello"}
+	testRunDispatch(t, ctx, dew.NewAction(action), dew.NewAction(post))
+	if action.Result != "user created" {
+		t.Fatalf("unexpected result: %s", action.Result)
+	}
+	if post.Result != "routed" {
+		t.Fatalf("unexpected result: %s", post.Result)
+	}
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+}
+
+func TestLoadRoutes_ActionAndQuery(t *testing.T) {
+	mux := dew.New()
+	err := mux.LoadRoutes([]dew.Route{
+		{Command: createUser{}, Op: dew.ACTION, Handler: func(ctx context.Context, cmd *createUser) error {
+			cmd.Result = "action"
+			return nil
+		}},
+		{Command: createUser{}, Op: dew.QUERY, Handler: func(ctx context.Context, cmd *createUser) error {
+			cmd.Result = "query"
+			return nil
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := dew.NewContext(context.Background(), mux)
+
+	action, err := dew.Dispatch(ctx, &createUser{Name: "john"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action.Result != "action" {
+		t.Fatalf("unexpected result: %s", action.Result)
+	}
+	if result := testRunQuery(t, ctx, &createUser{Name: "john"}); result.Result != "query" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+
+	// Actions and queries of the same type are resolved together without mixing them up.
+	query := &createUser{}
+	if err := dew.QueryAsync(ctx, dew.NewQuery(query), dew.NewQuery(&createUser{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Result != "query" {
+		t.Fatalf("unexpected result: %s", query.Result)
+	}
+}
+
+func TestLoadRoutes_Invalid(t *testing.T) {
+	h := new(userHandler)
+	mux := dew.New()
+	mux.Register(new(postHandler))
+
+	err := mux.LoadRoutes([]dew.Route{
+		{Command: createUser{}, Handler: h.CreateUser},
+		{Command: updateUser{}},
+		{Command: findUser{}, Handler: h.CreateUser},
+		{Command: findUser{}, Handler: h.FindUser, Op: dew.ACTION},
+		{Command: createPost{}, Handler: func(ctx context.Context, cmd *createPost) error { return nil }},
+	})
+	if err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+	for _, want := range []string{"route #1: missing handler", "route #2: handler", "route #3:", "route #4:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in error: %v", want, err)
+		}
+	}
+	if !errors.Is(err, dew.ErrDuplicateHandler) {
+		t.Fatalf("expected a duplicate handler error, got: %v", err)
+	}
+
+	// Nothing is registered when a route is invalid.
+	ctx := dew.NewContext(context.Background(), mux)
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "john"}); err == nil {
+		t.Fatal("expected a handler not found error, but got nil")
+	}
+}
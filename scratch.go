@@ -0,0 +1,73 @@
+package dew
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type requestKey struct{}
+
+// request holds the state shared by all the commands of a top-level request,
+// including re-entrant commands and queries executed by QueryAsync.
+type request struct {
+	scratch atomic.Pointer[sync.Map]
+}
+
+// requestContext binds the bus to the context of an execution and carries the state of the
+// top-level request in a single node, so a top-level execution allocates it only once.
+// It is kept as small as the context.WithValue node it replaces.
+type requestContext struct {
+	context.Context
+	bus *mux
+	req *request
+	// own is the state of the request started by this execution, if top-level.
+	own request
+	// stream is the consumer of the items emitted by this execution, if executed by QueryStream.
+	stream *streamContext
+}
+
+// newRequestContext returns the context of an execution on the bus, sharing the request of
+// the parent context if any, and the request state. The stream of a parent context of
+// QueryStream is bound to this execution only.
+func newRequestContext(parent context.Context, mx *mux) (context.Context, *request) {
+	c := &requestContext{Context: parent, bus: mx}
+	if s, ok := parent.(*streamContext); ok {
+		c.stream = s
+	}
+	if r, ok := parent.Value(requestKey{}).(*request); ok {
+		c.req = r
+	} else {
+		c.req = &c.own
+	}
+	return c, c.req
+}
+
+func (c *requestContext) Value(key any) any {
+	switch key.(type) {
+	case busKey:
+		return Bus(c.bus)
+	case requestKey:
+		return c.req
+	case streamKey:
+		if c.stream == nil {
+			return nil
+		}
+		return c.stream.fn
+	}
+	return c.Context.Value(key)
+}
+
+// Scratch returns the scratch map shared by all the commands of the current top-level request.
+// The map is created on first use. It returns nil if the context is not a command context.
+func Scratch(ctx context.Context) *sync.Map {
+	r, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return nil
+	}
+	if m := r.scratch.Load(); m != nil {
+		return m
+	}
+	r.scratch.CompareAndSwap(nil, &sync.Map{})
+	return r.scratch.Load()
+}
@@ -0,0 +1,53 @@
+package dew_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestScratch(t *testing.T) {
+	type findUserPost struct {
+		ID     int
+		Result string
+	}
+
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			v, _ := dew.Scratch(ctx).Load("tenant")
+			query.Result, _ = v.(string)
+			dew.Scratch(ctx).Store("user", query.ID)
+			return nil
+		},
+	))
+	mux.Register(dew.HandlerFunc[findUserPost](
+		func(ctx context.Context, query *findUserPost) error {
+			dew.Scratch(ctx).Store("tenant", "acme")
+			user, err := dew.Query(ctx, &findUser{ID: query.ID})
+			if err != nil {
+				return err
+			}
+			if v, _ := dew.Scratch(ctx).Load("user"); v != query.ID {
+				t.Errorf("expected the re-entrant handler to write the scratch, got: %v", v)
+			}
+			query.Result = user.Result
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if result := testRunQuery(t, ctx, &findUserPost{ID: 1}); result.Result != "acme" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+
+	// each top-level request has its own scratch
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+
+	if dew.Scratch(context.Background()) != nil {
+		t.Fatal("expected no scratch outside of a command")
+	}
+}
@@ -0,0 +1,54 @@
+package dew
+
+import (
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// SlogMiddleware returns a command middleware logging each command when it starts and ends,
+// with its type, operation type, and, when it ends, its duration and error.
+// Failed commands are logged at the error level, everything else at the debug level.
+// The identity of the request set with WithIdentity and the transport set with WithTransport
+// are logged, and the logger handler receives the context of the command, so it can add
+// its own request-scoped attributes.
+//
+//	bus.Use(dew.ALL, dew.SlogMiddleware(slog.Default()))
+func SlogMiddleware(logger *slog.Logger) func(next Middleware) Middleware {
+	return func(next Middleware) Middleware {
+		return MiddlewareFunc(func(ctx Context) error {
+			c := ctx.Context()
+			attrs := make([]slog.Attr, 0, 8)
+			if cmd := ctx.Command(); cmd != nil {
+				attrs = append(attrs, slog.String("command", reflect.TypeOf(cmd).Elem().String()))
+			}
+			attrs = append(attrs,
+				slog.String("op", ctx.Op().String()),
+				slog.String("transport", TransportFromContext(c)),
+			)
+			if identity, ok := IdentityFromContext(c); ok {
+				if identity.RequestID != "" {
+					attrs = append(attrs, slog.String("request_id", identity.RequestID))
+				}
+				if identity.UserID != "" {
+					attrs = append(attrs, slog.String("user_id", identity.UserID))
+				}
+				if identity.TenantID != "" {
+					attrs = append(attrs, slog.String("tenant_id", identity.TenantID))
+				}
+			}
+			logger.LogAttrs(c, slog.LevelDebug, "command started", attrs...)
+
+			start := time.Now()
+			err := next.Handle(ctx)
+			attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+			if err != nil {
+				attrs = append(attrs, slog.Any("error", err))
+				logger.LogAttrs(c, slog.LevelError, "command failed", attrs...)
+				return err
+			}
+			logger.LogAttrs(c, slog.LevelDebug, "command completed", attrs...)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package dew_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestSlogMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Use(dew.ALL, dew.SlogMiddleware(logger))
+	ctx := dew.WithIdentity(dew.NewContext(context.Background(), mux), dew.Identity{RequestID: "req-1"})
+
+	entries := func() []map[string]any {
+		var entries []map[string]any
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var entry map[string]any
+			if err := dec.Decode(&entry); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+		}
+		buf.Reset()
+		return entries
+	}
+
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("expected 2 entries, got %v", logged)
+	}
+	for i, msg := range []string{"command started", "command completed"} {
+		entry := logged[i]
+		if entry["msg"] != msg || entry["level"] != "DEBUG" || entry["command"] != "dew_test.findUser" ||
+			entry["op"] != "QUERY" || entry["request_id"] != "req-1" || entry["transport"] != dew.TransportInternal {
+			t.Fatalf("unexpected entry: %v", entry)
+		}
+	}
+	if _, ok := logged[1]["duration"]; !ok {
+		t.Fatalf("expected a duration: %v", logged[1])
+	}
+
+	if _, err := dew.Dispatch(ctx, &createUser{}); !errors.Is(err, errNameRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logged = entries()
+	if len(logged) != 2 {
+		t.Fatalf("expected 2 entries, got %v", logged)
+	}
+	if entry := logged[1]; entry["level"] != "ERROR" || entry["op"] != "ACTION" || entry["error"] != errNameRequired.Error() {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+
+	// successes are not logged at the info level
+	logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	mux = dew.New()
+	mux.Register(new(userHandler))
+	mux.Use(dew.ALL, dew.SlogMiddleware(logger))
+	ctx = dew.NewContext(context.Background(), mux)
+	testRunQuery(t, ctx, &findUser{ID: 1})
+	if logged := entries(); len(logged) != 0 {
+		t.Fatalf("unexpected entries: %v", logged)
+	}
+}
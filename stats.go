@@ -0,0 +1,51 @@
+package dew
+
+import "sync/atomic"
+
+// RuntimeStats reports the goroutines and context pooling of a bus and its groups,
+// e.g. for capacity planning.
+type RuntimeStats struct {
+	// ActiveGoroutines is the number of goroutines running a command for QueryAsync or DispatchAsync.
+	ActiveGoroutines int64
+	// SpawnedGoroutines is the total number of goroutines started for QueryAsync or DispatchAsync.
+	SpawnedGoroutines uint64
+	// PoolGets is the number of contexts taken from the context pool.
+	PoolGets uint64
+	// PoolMisses is the number of contexts allocated because the pool was empty.
+	PoolMisses uint64
+}
+
+// PoolHitRate returns the fraction of contexts reused from the pool, or 0 if none was taken.
+func (s RuntimeStats) PoolHitRate() float64 {
+	if s.PoolGets == 0 {
+		return 0
+	}
+	return float64(s.PoolGets-s.PoolMisses) / float64(s.PoolGets)
+}
+
+// runtimeStats holds the counters reported by RuntimeStats.
+type runtimeStats struct {
+	active     atomic.Int64
+	spawned    atomic.Uint64
+	poolGets   atomic.Uint64
+	poolMisses atomic.Uint64
+}
+
+// RuntimeStats returns the goroutine and context pool counters of the bus and its groups.
+func (mx *mux) RuntimeStats() RuntimeStats {
+	s := &mx.handlers.stats
+	// Load the misses first, so they never exceed the gets.
+	misses := s.poolMisses.Load()
+	return RuntimeStats{
+		ActiveGoroutines:  s.active.Load(),
+		SpawnedGoroutines: s.spawned.Load(),
+		PoolGets:          s.poolGets.Load(),
+		PoolMisses:        misses,
+	}
+}
+
+// acquire takes a context from the pool.
+func (mx *mux) acquire() *BusContext {
+	mx.handlers.stats.poolGets.Add(1)
+	return mx.pool.Get().(*BusContext)
+}
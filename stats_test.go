@@ -0,0 +1,82 @@
+package dew_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+)
+
+func TestRuntimeStats(t *testing.T) {
+	type slowQuery struct {
+		ID int
+	}
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(dew.HandlerFunc[slowQuery](
+		func(ctx context.Context, query *slowQuery) error {
+			started <- struct{}{}
+			<-unblock
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if stats := mux.RuntimeStats(); stats.ActiveGoroutines != 0 || stats.SpawnedGoroutines != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	const batches, size = 3, 4
+	var wg sync.WaitGroup
+	for i := 0; i < batches; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			queries := make([]dew.CommandHandler[dew.Command], size)
+			for j := range queries {
+				queries[j] = dew.NewQuery(&slowQuery{ID: j})
+			}
+			if err := dew.QueryAsync(ctx, queries...); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for i := 0; i < batches*size; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the queries")
+		}
+	}
+
+	if stats := mux.RuntimeStats(); stats.ActiveGoroutines != batches*size {
+		t.Fatalf("expected %d active goroutines, got %+v", batches*size, stats)
+	}
+
+	close(unblock)
+	wg.Wait()
+
+	stats := mux.RuntimeStats()
+	if stats.ActiveGoroutines != 0 {
+		t.Fatalf("expected no active goroutines, got %+v", stats)
+	}
+	if stats.SpawnedGoroutines != batches*size {
+		t.Fatalf("expected %d spawned goroutines, got %+v", batches*size, stats)
+	}
+	if stats.PoolGets < batches*(size+1) || stats.PoolMisses > stats.PoolGets {
+		t.Fatalf("unexpected pool stats: %+v", stats)
+	}
+
+	// the contexts are reused by sequential queries
+	for i := 0; i < 100; i++ {
+		testRunQuery(t, ctx, &findUser{ID: 1})
+	}
+	if rate := mux.RuntimeStats().PoolHitRate(); rate <= 0 || rate > 1 {
+		t.Fatalf("unexpected pool hit rate: %v", rate)
+	}
+}
@@ -0,0 +1,44 @@
+package dew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoStream is returned by Emit when the query is not executed with QueryStream.
+	ErrNoStream = errors.New("no stream in context")
+)
+
+type streamKey struct{}
+
+// streamContext carries the consumer of a streaming query to the context of its execution,
+// which answers streamKey, so the commands issued by the handler do not see the stream.
+type streamContext struct {
+	context.Context
+	fn any
+}
+
+// QueryStream executes the query and calls fn for each item the handler emits with Emit.
+// The handler stops streaming when fn returns an error, which is returned from Emit.
+// The stream is scoped to the query: the commands issued by the handler cannot emit to it.
+func QueryStream[T QueryAction, I any](ctx context.Context, query *T, fn func(item I) error) error {
+	_, err := Query(&streamContext{Context: ctx, fn: fn}, query)
+	return err
+}
+
+// Emit sends an item to the consumer of a query executed with QueryStream.
+// It returns ErrNoStream if the context does not carry a stream of items of type I,
+// e.g. in a command issued by the handler of the streaming query.
+func Emit[I any](ctx context.Context, item I) error {
+	v := ctx.Value(streamKey{})
+	if v == nil {
+		return ErrNoStream
+	}
+	fn, ok := v.(func(item I) error)
+	if !ok {
+		return fmt.Errorf("%w: unexpected item type %T", ErrNoStream, item)
+	}
+	return fn(item)
+}
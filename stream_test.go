@@ -0,0 +1,81 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type listUsers struct{}
+
+func TestQueryStream(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[listUsers](
+		func(ctx context.Context, query *listUsers) error {
+			for _, name := range []string{"john", "jane"} {
+				if err := dew.Emit(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	var names []string
+	err := dew.QueryStream(ctx, &listUsers{}, func(name string) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "john" || names[1] != "jane" {
+		t.Fatalf("unexpected items: %v", names)
+	}
+
+	// item type mismatch
+	err = dew.QueryStream(ctx, &listUsers{}, func(id int) error { return nil })
+	if !errors.Is(err, dew.ErrNoStream) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// not streaming
+	if _, err := dew.Query(ctx, &listUsers{}); !errors.Is(err, dew.ErrNoStream) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestQueryStream_Nested(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			return dew.Emit(ctx, "intruder")
+		},
+	))
+	mux.Register(dew.HandlerFunc[listUsers](
+		func(ctx context.Context, query *listUsers) error {
+			// a sub-query cannot emit to the stream of its parent
+			if _, err := dew.Query(ctx, &findUser{ID: 1}); !errors.Is(err, dew.ErrNoStream) {
+				return fmt.Errorf("unexpected error: %v", err)
+			}
+			return dew.Emit(ctx, "john")
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	var names []string
+	err := dew.QueryStream(ctx, &listUsers{}, func(name string) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "john" {
+		t.Fatalf("unexpected items: %v", names)
+	}
+}
@@ -0,0 +1,58 @@
+package dew
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrAmbiguousCommand is returned in strict mode when a command type is registered
+// both as an action and as a query.
+var ErrAmbiguousCommand = errors.New("ambiguous command")
+
+// WithStrictRegistration makes the bus reject the registration of a command type that is
+// already handled, as an action or as a query. Register and RegisterTyped panic, while
+// RegisterChecked, RegisterMany, and LoadRoutes return an error wrapping ErrDuplicateHandler
+// or ErrAmbiguousCommand. Without it, the last registered handler of a command type wins,
+// even if the command type was already executed.
+// The mode applies to the bus and all its groups.
+func WithStrictRegistration() Option {
+	return func(mx *mux) {
+		mx.handlers.strict = true
+	}
+}
+
+// checkStrict returns the errors of the registration of the handler methods in strict mode,
+// including conflicts between the methods themselves.
+func (r *registry) checkStrict(methods []handlerMethod) error {
+	if !r.strict {
+		return nil
+	}
+	set := r.load()
+	seen := make(map[handlerKey]string, len(methods))
+	name := func(op OpType, t reflect.Type) (string, bool) {
+		if prev, ok := seen[handlerKey{op: op, t: t}]; ok {
+			return prev, true
+		}
+		if prev, ok := set.entriesFor(op).Load(t); ok {
+			return prev.(*handler).name, true
+		}
+		return "", false
+	}
+	var errs []error
+	for _, m := range methods {
+		other := QUERY
+		if m.op == QUERY {
+			other = ACTION
+		}
+		if prev, ok := name(m.op, m.cmdType); ok {
+			errs = append(errs, fmt.Errorf("%w: %s for %v, already handled by %s",
+				ErrDuplicateHandler, m.name, m.cmdType, prev))
+		} else if prev, ok := name(other, m.cmdType); ok {
+			errs = append(errs, fmt.Errorf("%w: %s handles %v as %s, already handled as %s by %s",
+				ErrAmbiguousCommand, m.name, m.cmdType, m.op, other, prev))
+		}
+		seen[handlerKey{op: m.op, t: m.cmdType}] = m.name
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,69 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestWithStrictRegistration(t *testing.T) {
+	createUserAsQuery := dew.Route{
+		Command: createUser{},
+		Handler: func(_ context.Context, query *createUser) error { return nil },
+		Op:      dew.QUERY,
+	}
+
+	t.Run("Strict", func(t *testing.T) {
+		mux := dew.New(dew.WithStrictRegistration())
+		mux.Register(new(userHandler))
+
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, dew.ErrDuplicateHandler) {
+					t.Fatalf("unexpected panic: %v", err)
+				}
+			}()
+			mux.Group(func(mux dew.Bus) {
+				mux.Register(conflictingUserHandler{})
+			})
+		}()
+
+		if err := mux.RegisterMany(conflictingUserHandler{}); !errors.Is(err, dew.ErrDuplicateHandler) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mux.LoadRoutes([]dew.Route{createUserAsQuery}); !errors.Is(err, dew.ErrAmbiguousCommand) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// the registered handlers are unchanged
+		ctx := dew.NewContext(context.Background(), mux)
+		if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+			t.Fatalf("unexpected result: %s", result.Result)
+		}
+		if result, err := dew.Query(ctx, &createUser{Name: "john"}); err != nil || result.Result != "user created" {
+			t.Fatalf("unexpected result: %v, %v", result, err)
+		}
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		mux := dew.New()
+		mux.Register(new(userHandler))
+		ctx := dew.NewContext(context.Background(), mux)
+		if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "john" {
+			t.Fatalf("unexpected result: %s", result.Result)
+		}
+
+		mux.Register(conflictingUserHandler{})
+		if err := mux.LoadRoutes([]dew.Route{createUserAsQuery}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// the last registered handler wins, even for a query already executed
+		if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "conflict" {
+			t.Fatalf("unexpected result: %s", result.Result)
+		}
+	})
+}
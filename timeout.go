@@ -0,0 +1,78 @@
+package dew
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SetTimeout sets the timeout applied to every execution of commands of type T.
+// The handler and the command middlewares receive a context with the derived deadline.
+// It takes precedence over a timeout declared with a struct tag.
+func SetTimeout[T Command](bus Bus, d time.Duration) {
+	bus.(*mux).handlers.updateOptions(typeFor[T](), func(o *commandOptions) {
+		o.timeout = d
+	})
+}
+
+// DispatchTimeout executes the actions like DispatchMulti, with a context whose deadline is
+// d from now. The handlers and middlewares receive the derived context.
+func DispatchTimeout(ctx context.Context, d time.Duration, actions ...CommandHandler[Action]) error {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	return DispatchMulti(ctx, actions...)
+}
+
+// QueryTimeout executes the query like Query, with a context whose deadline is d from now.
+// The handler and middlewares receive the derived context.
+func QueryTimeout[T QueryAction](ctx context.Context, d time.Duration, query *T) (*T, error) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	return Query(ctx, query)
+}
+
+// DispatchWithTimeout executes the action like Dispatch, with a context whose deadline is
+// d from now. It returns context.DeadlineExceeded when the handler gives up on the deadline.
+func DispatchWithTimeout[T Action](ctx context.Context, action *T, d time.Duration) (*T, error) {
+	return action, DispatchTimeout(ctx, d, NewAction(action))
+}
+
+// QueryWithTimeout executes the query like Query, with a context whose deadline is d from now.
+// It returns context.DeadlineExceeded when the handler gives up on the deadline.
+func QueryWithTimeout[T QueryAction](ctx context.Context, query *T, d time.Duration) (*T, error) {
+	return QueryTimeout(ctx, d, query)
+}
+
+// Remaining returns the time left before the deadline of the context.
+// It reports false if the context has no deadline. The duration is negative
+// once the deadline has passed.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// parseTimeoutTag returns the timeout declared by the `dew:"timeout=..."` struct tag.
+// Besides SetTimeout, a command can declare its timeout with a tag on a blank field:
+//
+//	type ExportReport struct {
+//		_ struct{} `dew:"timeout=30s"`
+//	}
+func parseTimeoutTag(t reflect.Type) time.Duration {
+	if t.Kind() != reflect.Struct {
+		return 0
+	}
+	for i := 0; i < t.NumField(); i++ {
+		for _, opt := range strings.Split(t.Field(i).Tag.Get("dew"), ",") {
+			if v, ok := strings.CutPrefix(opt, "timeout="); ok {
+				if d, err := time.ParseDuration(v); err == nil {
+					return d
+				}
+			}
+		}
+	}
+	return 0
+}
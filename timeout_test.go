@@ -0,0 +1,186 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-dew/dew"
+)
+
+type slowReport struct {
+	_ struct{} `dew:"timeout=20ms"`
+}
+
+func waitOrDone(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			return waitOrDone(ctx, 200*time.Millisecond)
+		},
+	))
+	mux.Register(dew.HandlerFunc[findPost](
+		func(ctx context.Context, query *findPost) error {
+			return waitOrDone(ctx, 50*time.Millisecond)
+		},
+	))
+	mux.Register(dew.HandlerFunc[slowReport](
+		func(ctx context.Context, query *slowReport) error {
+			return waitOrDone(ctx, 200*time.Millisecond)
+		},
+	))
+	dew.SetTimeout[findUser](mux, 20*time.Millisecond)
+	ctx := dew.NewContext(context.Background(), mux)
+
+	now := time.Now()
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := time.Since(now); d > 150*time.Millisecond {
+		t.Fatalf("expected the query to time out early: %v", d)
+	}
+
+	// other types are not affected
+	if _, err := dew.Query(ctx, &findPost{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// timeout declared with a struct tag
+	if _, err := dew.Query(ctx, &slowReport{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDispatchTimeout(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[createUser](
+		func(ctx context.Context, action *createUser) error {
+			if action.Name == "slow" {
+				return waitOrDone(ctx, 200*time.Millisecond)
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("missing deadline")
+			}
+			return nil
+		},
+	))
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			if query.ID == 2 {
+				return waitOrDone(ctx, 200*time.Millisecond)
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("missing deadline")
+			}
+			query.Result = "john"
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if err := dew.DispatchTimeout(ctx, time.Second, dew.NewAction(&createUser{Name: "fast"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	err := dew.DispatchTimeout(ctx, 20*time.Millisecond, dew.NewAction(&createUser{Name: "slow"}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := time.Since(now); d > 150*time.Millisecond {
+		t.Fatalf("expected the action to time out early: %v", d)
+	}
+
+	if result, err := dew.QueryTimeout(ctx, time.Second, &findUser{ID: 1}); err != nil || result.Result != "john" {
+		t.Fatalf("unexpected result: %v, %v", result, err)
+	}
+	if _, err := dew.QueryTimeout(ctx, 20*time.Millisecond, &findUser{ID: 2}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[createUser](
+		func(ctx context.Context, action *createUser) error {
+			if action.Name == "slow" {
+				return waitOrDone(ctx, 200*time.Millisecond)
+			}
+			return nil
+		},
+	))
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			if query.ID == 2 {
+				return waitOrDone(ctx, 200*time.Millisecond)
+			}
+			query.Result = "john"
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if action, err := dew.DispatchWithTimeout(ctx, &createUser{Name: "fast"}, time.Second); err != nil || action.Name != "fast" {
+		t.Fatalf("unexpected result: %v, %v", action, err)
+	}
+	if _, err := dew.DispatchWithTimeout(ctx, &createUser{Name: "slow"}, 20*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dew.QueryWithTimeout(ctx, &findUser{ID: 2}, 20*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The pooled contexts of the timed out executions must be reusable.
+	for i := 0; i < 10; i++ {
+		if result, err := dew.QueryWithTimeout(ctx, &findUser{ID: 1}, time.Second); err != nil || result.Result != "john" {
+			t.Fatalf("unexpected result: %v, %v", result, err)
+		}
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	var remaining time.Duration
+	var hasDeadline bool
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[findUser](
+		func(ctx context.Context, query *findUser) error {
+			remaining, hasDeadline = dew.Remaining(ctx)
+			return nil
+		},
+	))
+
+	t.Run("NoDeadline", func(t *testing.T) {
+		ctx := dew.NewContext(context.Background(), mux)
+		testRunQuery(t, ctx, &findUser{ID: 1})
+		if hasDeadline || remaining != 0 {
+			t.Fatalf("unexpected remaining budget: %v, %v", remaining, hasDeadline)
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		ctx = dew.NewContext(ctx, mux)
+		testRunQuery(t, ctx, &findUser{ID: 1})
+		if !hasDeadline || remaining <= 50*time.Second || remaining > time.Minute {
+			t.Fatalf("unexpected remaining budget: %v, %v", remaining, hasDeadline)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		if remaining, ok := dew.Remaining(ctx); !ok || remaining >= 0 {
+			t.Fatalf("unexpected remaining budget: %v, %v", remaining, ok)
+		}
+	})
+}
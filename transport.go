@@ -0,0 +1,28 @@
+package dew
+
+import "context"
+
+// Transports set by the adapters of this module.
+const (
+	// TransportInternal is the transport of the commands not received by an adapter,
+	// e.g. dispatched directly or issued by a handler.
+	TransportInternal = "internal"
+	// TransportHTTP is the transport of the commands received by the dewhttp adapters.
+	TransportHTTP = "http"
+)
+
+type transportKey struct{}
+
+// WithTransport returns a new context recording the transport the commands were received by,
+// e.g. "http", "grpc" or "cli". It is set by the transport adapters.
+func WithTransport(ctx context.Context, transport string) context.Context {
+	return context.WithValue(ctx, transportKey{}, transport)
+}
+
+// TransportFromContext returns the transport set with WithTransport, or TransportInternal if none was set.
+func TransportFromContext(ctx context.Context) string {
+	if transport, ok := ctx.Value(transportKey{}).(string); ok {
+		return transport
+	}
+	return TransportInternal
+}
@@ -0,0 +1,31 @@
+package dew_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestTransportFromContext(t *testing.T) {
+	type whoCalls struct {
+		Transport string
+	}
+
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[whoCalls](
+		func(ctx context.Context, query *whoCalls) error {
+			query.Transport = dew.TransportFromContext(ctx)
+			return nil
+		},
+	))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	if result := testRunQuery(t, ctx, &whoCalls{}); result.Transport != dew.TransportInternal {
+		t.Fatalf("unexpected transport: %s", result.Transport)
+	}
+	ctx = dew.WithTransport(ctx, "grpc")
+	if result := testRunQuery(t, ctx, &whoCalls{}); result.Transport != "grpc" {
+		t.Fatalf("unexpected transport: %s", result.Transport)
+	}
+}
@@ -0,0 +1,55 @@
+package dew
+
+import "reflect"
+
+// Unregister removes the handlers of the command type of the sample,
+// e.g. CreateUser{} or (*CreateUser)(nil), for both actions and queries.
+// It reports whether a handler was removed.
+func (mx *mux) Unregister(command any) bool {
+	t := commandType(command)
+	if t == nil {
+		return false
+	}
+	return mx.UnregisterType(t)
+}
+
+// commandType returns the command type of the sample, e.g. CreateUser{} or (*CreateUser)(nil).
+// It returns nil if the sample is nil.
+func commandType(command any) reflect.Type {
+	t := reflect.TypeOf(command)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// UnregisterType removes the handlers of the command type for both actions and queries.
+// It reports whether a handler was removed.
+// The handlers are replaced with a new set without the command type, so that resolutions
+// cached for the previous set are never served again. Dispatches in flight are not affected.
+func (mx *mux) UnregisterType(t reflect.Type) bool {
+	r := mx.handlers
+	r.write.Lock()
+	defer r.write.Unlock()
+
+	current := r.load()
+	found := false
+	for _, op := range []OpType{ACTION, QUERY} {
+		if _, ok := current.entriesFor(op).Load(t); ok {
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+
+	set := newHandlerSet()
+	current.rangeAll(func(key handlerKey, h *handler) bool {
+		if key.t != t {
+			set.entriesFor(key.op).Store(key.t, h)
+		}
+		return true
+	})
+	r.current.Store(set)
+	return true
+}
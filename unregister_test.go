@@ -0,0 +1,79 @@
+package dew_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+func TestUnregister(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(userHandler))
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	// Resolve once so that the handler is cached.
+	testRunQuery(t, ctx, &findUser{ID: 1})
+
+	if !mux.Unregister(findUser{}) {
+		t.Fatal("expected the handler to be removed")
+	}
+	if mux.Unregister(&findUser{}) {
+		t.Fatal("expected no handler to be removed")
+	}
+	if _, err := dew.Query(ctx, &findUser{ID: 1}); err == nil {
+		t.Fatal("expected a handler not found error, but got nil")
+	}
+
+	// Other handlers are kept.
+	testRunQuery(t, ctx, &findPost{ID: 1})
+	testRunDispatch(t, ctx, dew.NewAction(&createUser{Name: "john"}))
+
+	// A new handler can be registered for the type.
+	mux.Register(dew.HandlerFunc[findUser](func(ctx context.Context, query *findUser) error {
+		query.Result = "jane"
+		return nil
+	}))
+	if result := testRunQuery(t, ctx, &findUser{ID: 1}); result.Result != "jane" {
+		t.Fatalf("unexpected result: %s", result.Result)
+	}
+
+	if !mux.UnregisterType(reflect.TypeOf(createUser{})) {
+		t.Fatal("expected the handler to be removed")
+	}
+	if _, err := dew.Dispatch(ctx, &createUser{Name: "john"}); err == nil {
+		t.Fatal("expected a handler not found error, but got nil")
+	}
+}
+
+func TestUnregister_Concurrent(t *testing.T) {
+	mux := dew.New()
+	mux.Register(new(postHandler))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				mux.Register(new(userHandler))
+				mux.Unregister(findUser{})
+				mux.Unregister(createUser{})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, _ = dew.Query(ctx, &findUser{ID: 1})
+				if _, err := dew.Query(ctx, &findPost{ID: 1}); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
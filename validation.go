@@ -0,0 +1,79 @@
+package dew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MultiValidator is implemented by actions reporting all their validation errors at once,
+// e.g. "name required" and "email invalid" together. When an action implements it,
+// ValidateAll is called instead of Validate, and the errors are joined.
+type MultiValidator interface {
+	// ValidateAll validates the action and returns all the errors found.
+	ValidateAll(context.Context) []error
+}
+
+// validateAction validates the action with ValidateAll if it is a MultiValidator, with Validate otherwise.
+// The returned error wraps ErrValidationFailed.
+func validateAction(ctx context.Context, action Action) error {
+	var err error
+	if v, ok := action.(MultiValidator); ok {
+		err = errors.Join(v.ValidateAll(ctx)...)
+	} else {
+		err = action.Validate(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	return nil
+}
+
+// ValidationErrors holds per-field validation messages.
+// Actions can return it from Validate so that callers can render field errors;
+// it is preserved by DispatchMulti and can be recovered with errors.As.
+type ValidationErrors struct {
+	// Fields maps a field name to its validation message.
+	Fields map[string]string
+}
+
+// Add records a validation message for the given field.
+func (e *ValidationErrors) Add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	e.Fields[field] = message
+}
+
+// Err returns e if any field error has been recorded, or nil otherwise.
+func (e *ValidationErrors) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationErrors) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	var b strings.Builder
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(field)
+		b.WriteString(": ")
+		b.WriteString(e.Fields[field])
+	}
+	return b.String()
+}
+
+// Unwrap returns ErrValidationFailed.
+func (e *ValidationErrors) Unwrap() error {
+	return ErrValidationFailed
+}
@@ -0,0 +1,108 @@
+package dew_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-dew/dew"
+)
+
+type registerAccount struct {
+	Email    string
+	Password string
+}
+
+func (c registerAccount) Validate(_ context.Context) error {
+	var errs dew.ValidationErrors
+	if c.Email == "" {
+		errs.Add("email", "is required")
+	}
+	if len(c.Password) < 8 {
+		errs.Add("password", "must be at least 8 characters")
+	}
+	return errs.Err()
+}
+
+func TestValidationErrors(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[registerAccount](func(ctx context.Context, cmd *registerAccount) error {
+		return nil
+	}))
+
+	ctx := dew.NewContext(context.Background(), mux)
+
+	t.Run("FieldErrors", func(t *testing.T) {
+		_, err := dew.Dispatch(ctx, &registerAccount{Password: "short"})
+		if !errors.Is(err, dew.ErrValidationFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var verrs *dew.ValidationErrors
+		if !errors.As(err, &verrs) {
+			t.Fatalf("expected ValidationErrors, got: %v", err)
+		}
+		if len(verrs.Fields) != 2 {
+			t.Fatalf("unexpected fields: %v", verrs.Fields)
+		}
+		if verrs.Fields["email"] != "is required" {
+			t.Fatalf("unexpected email message: %q", verrs.Fields["email"])
+		}
+		if got, want := err.Error(), "validation failed: email: is required; password: must be at least 8 characters"; got != want {
+			t.Fatalf("unexpected message: %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		if _, err := dew.Dispatch(ctx, &registerAccount{Email: "john@example.com", Password: "password"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+var (
+	errEmailInvalid = errors.New("email invalid")
+	errNameMissing  = errors.New("name required")
+)
+
+type signUp struct {
+	Name  string
+	Email string
+}
+
+func (c signUp) Validate(_ context.Context) error {
+	return errors.New("Validate must not be called")
+}
+
+func (c signUp) ValidateAll(_ context.Context) []error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, errNameMissing)
+	}
+	if c.Email != "" && !strings.Contains(c.Email, "@") {
+		errs = append(errs, errEmailInvalid)
+	}
+	return errs
+}
+
+func TestMultiValidator(t *testing.T) {
+	mux := dew.New()
+	mux.Register(dew.HandlerFunc[signUp](func(ctx context.Context, cmd *signUp) error {
+		return nil
+	}))
+	ctx := dew.NewContext(context.Background(), mux)
+
+	_, err := dew.Dispatch(ctx, &signUp{Email: "john"})
+	if !errors.Is(err, dew.ErrValidationFailed) || !errors.Is(err, errNameMissing) || !errors.Is(err, errEmailInvalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = dew.DispatchMulti(ctx, dew.NewAction(&signUp{Name: "john", Email: "john"}))
+	if !errors.Is(err, errEmailInvalid) || errors.Is(err, errNameMissing) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := dew.Dispatch(ctx, &signUp{Name: "john", Email: "john@example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}